@@ -0,0 +1,252 @@
+package grds
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxAdvisoryLockName MySQL 锁名的最大长度
+const MaxAdvisoryLockName = 64
+
+// AdvisoryLockCheckInterval 检查锁是否仍被持有的间隔
+const AdvisoryLockCheckInterval = time.Second
+
+var (
+	// ErrLockTimeout 在超时时间内没有获取到锁
+	ErrLockTimeout = errors.New("advisory lock timeout")
+	// ErrLockLost 锁已丢失：连接断开或锁被其他方式释放
+	ErrLockLost = errors.New("advisory lock lost")
+	// ErrLockNotHeld 释放时锁已不再被当前连接持有
+	ErrLockNotHeld = errors.New("advisory lock not held")
+)
+
+// AdvisoryLockName 返回实际使用的锁名，超过 64 个字符时使用 grds: 加 SHA-1 摘要
+func AdvisoryLockName(name string) string {
+	if utf8.RuneCountInString(name) <= MaxAdvisoryLockName {
+		return name
+	}
+	sum := sha1.Sum([]byte(name))
+	return "grds:" + hex.EncodeToString(sum[:])
+}
+
+// AdvisoryLock 命名锁（GET_LOCK），固定占用一个连接直到释放
+//
+// MySQL 命名锁属于会话，连接断开时自动释放。锁持有期间后台定期检查连接，
+// 发现锁丢失时关闭 Lost() 通道。
+type AdvisoryLock struct {
+	name string
+	key  string
+	conn *sql.Conn
+
+	connMu   sync.Mutex // 同一连接不能并发执行查询，IsHeld 与后台检查需要串行
+	mu       sync.Mutex
+	released bool
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// AdvisoryLock 获取命名锁
+//
+// timeout 为等待时间，小于 0 时一直等待（直到 ctx 取消），为 0 时立即返回。
+// 超时返回 ErrLockTimeout。使用完必须调用 Unlock，否则连接不会归还连接池。
+func (c *Client) AdvisoryLock(ctx context.Context, name string, timeout time.Duration) (*AdvisoryLock, error) {
+	sqlDB, err := c.SqlDB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := int64(-1)
+	if timeout >= 0 {
+		seconds = int64((timeout + time.Second - 1) / time.Second)
+	}
+
+	key := AdvisoryLockName(name)
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", key, seconds).Scan(&got); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		_ = conn.Close()
+		if !got.Valid {
+			return nil, fmt.Errorf("get lock %q failed", name)
+		}
+		return nil, ErrLockTimeout
+	}
+
+	lock := &AdvisoryLock{
+		name: name,
+		key:  key,
+		conn: conn,
+		lost: make(chan struct{}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go lock.watch()
+	return lock, nil
+}
+
+// WithLock 持有命名锁执行 fn，结束后释放
+//
+// 等待锁的时间受 ctx 控制。锁丢失时取消传给 fn 的 context，fn 正常返回时 WithLock 返回 ErrLockLost。
+func (c *Client) WithLock(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	lock, err := c.AdvisoryLock(ctx, name, -1)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel()
+		case <-fnCtx.Done():
+		}
+	}()
+
+	err = fn(fnCtx)
+	lost := false
+	select {
+	case <-lock.Lost():
+		lost = true
+	default:
+	}
+	unlockErr := lock.Unlock(context.Background())
+	if err != nil {
+		return err
+	}
+	if lost || errors.Is(unlockErr, ErrLockNotHeld) {
+		return ErrLockLost
+	}
+	return unlockErr
+}
+
+// Name 锁名
+func (l *AdvisoryLock) Name() string {
+	return l.name
+}
+
+// Lost 锁丢失时关闭的通道
+func (l *AdvisoryLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// IsHeld 查询锁是否仍被当前连接持有
+//
+// 只有查询确认锁不再属于当前连接或连接已断开时才标记丢失；ctx 取消等其他错误原样返回，
+// 由后台检查确认锁的状态。
+func (l *AdvisoryLock) IsHeld(ctx context.Context) (bool, error) {
+	l.connMu.Lock()
+	defer l.connMu.Unlock()
+	l.mu.Lock()
+	released := l.released
+	l.mu.Unlock()
+	if released {
+		return false, nil
+	}
+	select {
+	case <-l.lost:
+		return false, nil
+	default:
+	}
+
+	held, err := l.check(ctx)
+	switch {
+	case err == nil && !held:
+		l.markLost()
+	case err != nil && ctx.Err() == nil && IsConnectionError(err):
+		l.markLost()
+	}
+	return held, err
+}
+
+// Unlock 释放锁并归还连接，锁已丢失时返回 ErrLockNotHeld
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	l.mu.Unlock()
+
+	close(l.stop)
+	<-l.done
+
+	l.connMu.Lock()
+	defer l.connMu.Unlock()
+	var released sql.NullInt64
+	if err := l.conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", l.key).Scan(&released); err != nil {
+		// 无法确认锁已释放的连接不能归还连接池
+		discardConn(l.conn)
+		l.markLost()
+		return err
+	}
+	_ = l.conn.Close()
+	if released.Int64 != 1 {
+		l.markLost()
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// check 查询锁的持有者是否为当前连接，调用方需持有 connMu
+func (l *AdvisoryLock) check(ctx context.Context) (bool, error) {
+	var held sql.NullBool
+	err := l.conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?) = CONNECTION_ID()", l.key).Scan(&held)
+	return held.Valid && held.Bool, err
+}
+
+// watch 定期检查锁，连接断开或锁不再被持有时标记丢失
+func (l *AdvisoryLock) watch() {
+	defer close(l.done)
+	ticker := time.NewTicker(AdvisoryLockCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-l.lost:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), AdvisoryLockCheckInterval)
+			l.connMu.Lock()
+			held, err := l.check(ctx)
+			l.connMu.Unlock()
+			cancel()
+			if err != nil || !held {
+				l.markLost()
+				return
+			}
+		}
+	}
+}
+
+// markLost 标记锁丢失
+func (l *AdvisoryLock) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
+
+// discardConn 关闭连接而不归还连接池
+//
+// Conn.Raw（Go 1.13 起提供）的回调返回 driver.ErrBadConn 时，database/sql 会关闭底层连接。
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
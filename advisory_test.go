@@ -0,0 +1,170 @@
+package grds
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAdvisoryLockName(t *testing.T) {
+	short := strings.Repeat("a", MaxAdvisoryLockName)
+	if got := AdvisoryLockName(short); got != short {
+		t.Errorf("AdvisoryLockName(64 chars) = %q, want unchanged", got)
+	}
+	// 按字符而不是字节计算长度
+	multibyte := strings.Repeat("锁", MaxAdvisoryLockName)
+	if got := AdvisoryLockName(multibyte); got != multibyte {
+		t.Errorf("AdvisoryLockName(64 runes) = %q, want unchanged", got)
+	}
+
+	long := strings.Repeat("a", MaxAdvisoryLockName+1)
+	got := AdvisoryLockName(long)
+	if !strings.HasPrefix(got, "grds:") || len(got) > MaxAdvisoryLockName {
+		t.Errorf("AdvisoryLockName(65 chars) = %q, want grds: digest", got)
+	}
+	if got != AdvisoryLockName(long) || got == AdvisoryLockName(long+"b") {
+		t.Errorf("digest names must be stable and distinct")
+	}
+}
+
+const (
+	getLockSQL     = "SELECT GET_LOCK(?, ?)"
+	releaseLockSQL = "SELECT RELEASE_LOCK(?)"
+	isUsedLockSQL  = "SELECT IS_USED_LOCK(?) = CONNECTION_ID()"
+)
+
+func TestAdvisoryLockAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		seconds  int64
+		result   interface{}
+		acquired bool
+		err      error // 获取失败时的错误，GET_LOCK 返回 NULL 时为 nil
+	}{
+		{"acquired", 1500 * time.Millisecond, 2, 1, true, nil},
+		{"wait forever", -1, -1, 1, true, nil},
+		{"timeout", 0, 0, 0, false, ErrLockTimeout},
+		{"error", time.Second, 1, nil, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockClient(t, nil)
+			mock.ExpectQuery(getLockSQL).WithArgs("job", tt.seconds).
+				WillReturnRows(sqlmock.NewRows([]string{"got"}).AddRow(tt.result))
+			if tt.acquired {
+				mock.ExpectQuery(releaseLockSQL).WithArgs("job").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+			}
+
+			lock, err := c.AdvisoryLock(context.Background(), "job", tt.timeout)
+			if !tt.acquired {
+				if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) || (tt.err == nil && errors.Is(err, ErrLockTimeout)) {
+					t.Errorf("AdvisoryLock() = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := lock.Unlock(context.Background()); err != nil {
+				t.Errorf("Unlock() = %v", err)
+			}
+		})
+	}
+}
+
+func TestAdvisoryLockIsHeld(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(q *sqlmock.ExpectedQuery)
+		cancel bool
+		held   bool
+		lost   bool
+	}{
+		{"held", func(q *sqlmock.ExpectedQuery) {
+			q.WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+		}, false, true, false},
+		{"released elsewhere", func(q *sqlmock.ExpectedQuery) {
+			q.WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(nil))
+		}, false, false, true},
+		{"connection broken", func(q *sqlmock.ExpectedQuery) {
+			q.WillReturnError(driver.ErrBadConn)
+		}, false, false, true},
+		{"query error", func(q *sqlmock.ExpectedQuery) {
+			q.WillReturnError(errors.New("query failed"))
+		}, false, false, false},
+		{"context canceled", func(q *sqlmock.ExpectedQuery) {
+			q.WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+		}, true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockClient(t, nil)
+			mock.ExpectQuery(getLockSQL).WithArgs("job", int64(-1)).
+				WillReturnRows(sqlmock.NewRows([]string{"got"}).AddRow(1))
+			tt.expect(mock.ExpectQuery(isUsedLockSQL).WithArgs("job"))
+
+			lock, err := c.AdvisoryLock(context.Background(), "job", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+			held, err := lock.IsHeld(ctx)
+			cancel()
+			if held != tt.held {
+				t.Errorf("IsHeld() = %v, %v, want held %v", held, err, tt.held)
+			}
+			select {
+			case <-lock.Lost():
+				if !tt.lost {
+					t.Errorf("lock marked lost after %v", err)
+				}
+			default:
+				if tt.lost {
+					t.Errorf("lock not marked lost after %v", err)
+				}
+			}
+
+			// 连接断开后无需 RELEASE_LOCK；锁被其他方式释放时返回 ErrLockNotHeld
+			switch tt.name {
+			case "connection broken":
+			case "released elsewhere":
+				mock.ExpectQuery(releaseLockSQL).WithArgs("job").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(nil))
+			default:
+				mock.ExpectQuery(releaseLockSQL).WithArgs("job").
+					WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+			}
+			err = lock.Unlock(context.Background())
+			if tt.name == "released elsewhere" && !errors.Is(err, ErrLockNotHeld) {
+				t.Errorf("Unlock() = %v, want ErrLockNotHeld", err)
+			}
+		})
+	}
+}
+
+func TestWithLockLost(t *testing.T) {
+	c, mock := newMockClient(t, nil)
+	mock.ExpectQuery(getLockSQL).WithArgs("job", int64(-1)).
+		WillReturnRows(sqlmock.NewRows([]string{"got"}).AddRow(1))
+	mock.ExpectQuery(releaseLockSQL).WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(0))
+
+	ran := false
+	err := c.WithLock(context.Background(), "job", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if !ran || !errors.Is(err, ErrLockLost) {
+		t.Errorf("WithLock() ran %v, error %v, want ErrLockLost", ran, err)
+	}
+}
@@ -0,0 +1,375 @@
+package grds
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Aggregation 聚合表达式，由 Sum、Avg、Count、Max、Min 创建
+type Aggregation struct {
+	Func     string // 聚合函数：SUM、AVG、COUNT、MAX、MIN
+	Column   string // 列名，COUNT 可使用 "*"
+	Alias    string // 结果别名，默认为 func_column，如 sum_amount、count、count_distinct_user_id
+	Distinct bool   // 是否 DISTINCT
+}
+
+// Sum SUM 聚合
+func Sum(column string) Aggregation {
+	return Aggregation{Func: "SUM", Column: column}
+}
+
+// Avg AVG 聚合
+func Avg(column string) Aggregation {
+	return Aggregation{Func: "AVG", Column: column}
+}
+
+// Count COUNT 聚合，column 为 "*" 时统计行数
+func Count(column string) Aggregation {
+	return Aggregation{Func: "COUNT", Column: column}
+}
+
+// CountDistinct COUNT(DISTINCT column) 聚合
+func CountDistinct(column string) Aggregation {
+	return Aggregation{Func: "COUNT", Column: column, Distinct: true}
+}
+
+// Max MAX 聚合
+func Max(column string) Aggregation {
+	return Aggregation{Func: "MAX", Column: column}
+}
+
+// Min MIN 聚合
+func Min(column string) Aggregation {
+	return Aggregation{Func: "MIN", Column: column}
+}
+
+// As 设置结果别名
+func (a Aggregation) As(alias string) Aggregation {
+	a.Alias = alias
+	return a
+}
+
+// alias 返回结果别名
+func (a Aggregation) alias() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	column := a.Column
+	if i := strings.LastIndexByte(column, '.'); i >= 0 {
+		column = column[i+1:]
+	}
+	fn := strings.ToLower(a.Func)
+	if a.Distinct {
+		fn += "_distinct"
+	}
+	if column == "*" {
+		return fn
+	}
+	return fn + "_" + column
+}
+
+// aggregateExpr 生成聚合 SQL 表达式
+func (qb *QueryBuilder) aggregateExpr(a Aggregation) (string, error) {
+	fn := strings.ToUpper(a.Func)
+	switch fn {
+	case "SUM", "AVG", "COUNT", "MAX", "MIN":
+	default:
+		return "", fmt.Errorf("unsupported aggregate function %q", a.Func)
+	}
+
+	column := "*"
+	if a.Column != "*" || fn != "COUNT" || a.Distinct {
+		col, err := qb.quoteColumn(a.Column)
+		if err != nil {
+			return "", err
+		}
+		column = col
+	}
+	if a.Distinct {
+		column = "DISTINCT " + column
+	}
+
+	alias, err := QuoteIdentifier(a.alias())
+	if err != nil || strings.Contains(alias, ".") {
+		return "", fmt.Errorf("%w: alias %q", ErrInvalidIdentifier, a.alias())
+	}
+	return fn + "(" + column + ") AS " + alias, nil
+}
+
+// AggregateResult 聚合结果，按别名取值
+//
+// 值已按列类型转换：整数为 int64，小数为 float64，时间为 time.Time（开启 parseTime 时），
+// 其余为 string；空集合上的 SUM/AVG/MAX/MIN 结果为 NULL。
+type AggregateResult struct {
+	values map[string]interface{}
+}
+
+// Value 获取原始值，NULL 或别名不存在时返回 nil
+func (r *AggregateResult) Value(alias string) interface{} {
+	return r.values[alias]
+}
+
+// IsNull 结果是否为 NULL
+func (r *AggregateResult) IsNull(alias string) bool {
+	return r.values[alias] == nil
+}
+
+// Int64 获取整数结果，NULL 返回 0
+func (r *AggregateResult) Int64(alias string) int64 {
+	switch v := r.values[alias].(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// Float64 获取浮点结果，NULL 返回 0
+func (r *AggregateResult) Float64(alias string) float64 {
+	switch v := r.values[alias].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// String 获取字符串结果，NULL 返回空字符串
+func (r *AggregateResult) String(alias string) string {
+	switch v := r.values[alias].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Time 获取时间结果，NULL 或无法解析时返回零值
+func (r *AggregateResult) Time(alias string) time.Time {
+	switch v := r.values[alias].(type) {
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05.999999", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Values 所有结果，键为别名
+func (r *AggregateResult) Values() map[string]interface{} {
+	return r.values
+}
+
+// AggregateGroup 分组聚合中的一组
+type AggregateGroup struct {
+	*AggregateResult
+	Keys []interface{} // 分组列的值，顺序与 groupColumns 一致
+}
+
+// GroupedResults 分组聚合结果
+type GroupedResults []*AggregateGroup
+
+// Lookup 按分组值查找，值按字符串形式比较，nil 匹配 NULL
+func (gs GroupedResults) Lookup(keys ...interface{}) *AggregateGroup {
+	want := groupKey(keys)
+	for _, g := range gs {
+		if groupKey(g.Keys) == want {
+			return g
+		}
+	}
+	return nil
+}
+
+// Map 按分组键（各分组值以 "|" 连接）建立索引
+func (gs GroupedResults) Map() map[string]*AggregateGroup {
+	m := make(map[string]*AggregateGroup, len(gs))
+	for _, g := range gs {
+		m[groupKey(g.Keys)] = g
+	}
+	return m
+}
+
+// groupKey 生成分组键
+func groupKey(keys []interface{}) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		switch v := k.(type) {
+		case nil:
+			parts = append(parts, "NULL")
+		case []byte:
+			parts = append(parts, string(v))
+		case time.Time:
+			parts = append(parts, v.Format("2006-01-02 15:04:05.999999"))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// Aggregate 在一条查询中计算多个聚合，忽略已设置的排序和分页
+//
+//	res, err := grds.Model(&Order{}).WhereEq("status", 1).
+//	    Aggregate(grds.Sum("amount"), grds.Count("*"), grds.Max("created_at").As("last"))
+//	total := res.Float64("sum_amount")
+func (qb *QueryBuilder) Aggregate(aggs ...Aggregation) (*AggregateResult, error) {
+	groups, err := qb.aggregate(nil, aggs)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return &AggregateResult{values: map[string]interface{}{}}, nil
+	}
+	return groups[0].AggregateResult, nil
+}
+
+// GroupAggregate 按列分组计算聚合，每组一行
+//
+//	groups, err := grds.Model(&Order{}).GroupAggregate([]string{"status"}, grds.Sum("amount"), grds.Count("*"))
+//	paid := groups.Lookup(1).Float64("sum_amount")
+func (qb *QueryBuilder) GroupAggregate(groupColumns []string, aggs ...Aggregation) (GroupedResults, error) {
+	if len(groupColumns) == 0 {
+		return nil, newError("aggregate", qb.db, errors.New("group columns are required"))
+	}
+	return qb.aggregate(groupColumns, aggs)
+}
+
+// aggregate 执行聚合查询
+func (qb *QueryBuilder) aggregate(groupColumns []string, aggs []Aggregation) (GroupedResults, error) {
+	if len(aggs) == 0 {
+		return nil, newError("aggregate", qb.db, errors.New("at least one aggregation is required"))
+	}
+
+	selects := make([]string, 0, len(groupColumns)+len(aggs))
+	groups := make([]string, 0, len(groupColumns))
+	for _, column := range groupColumns {
+		col, err := qb.quoteColumn(column)
+		if err != nil {
+			return nil, newError("aggregate", qb.db, err)
+		}
+		selects = append(selects, col)
+		groups = append(groups, col)
+	}
+	for _, a := range aggs {
+		expr, err := qb.aggregateExpr(a)
+		if err != nil {
+			return nil, newError("aggregate", qb.db, err)
+		}
+		selects = append(selects, expr)
+	}
+
+	tx := qb.db.Session(&gorm.Session{}).Select(strings.Join(selects, ", "))
+	if len(groups) > 0 {
+		tx = tx.Group(strings.Join(groups, ", "))
+	} else {
+		// 不分组时只有一行结果，排序无意义，OFFSET 还会把这一行跳过
+		delete(tx.Statement.Clauses, "ORDER BY")
+		delete(tx.Statement.Clauses, "LIMIT")
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		return nil, newError("aggregate", tx, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, newError("aggregate", tx, err)
+	}
+
+	var results GroupedResults
+	for rows.Next() {
+		values, err := scanRow(rows, columnTypes)
+		if err != nil {
+			return nil, newError("aggregate", tx, err)
+		}
+
+		group := &AggregateGroup{
+			AggregateResult: &AggregateResult{values: make(map[string]interface{}, len(aggs))},
+			Keys:            values[:len(groupColumns)],
+		}
+		for i, a := range aggs {
+			group.values[a.alias()] = values[len(groupColumns)+i]
+		}
+		results = append(results, group)
+	}
+	return results, newError("aggregate", tx, rows.Err())
+}
+
+// scanRow 扫描一行并按列类型转换值
+func scanRow(rows *sql.Rows, columnTypes []*sql.ColumnType) ([]interface{}, error) {
+	values := make([]interface{}, len(columnTypes))
+	ptrs := make([]interface{}, len(columnTypes))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	for i, ct := range columnTypes {
+		values[i] = normalizeValue(values[i], ct)
+	}
+	return values, nil
+}
+
+// normalizeValue 把驱动返回的值转换为 Go 类型
+//
+// 文本协议下 MySQL 驱动对所有列返回 []byte，这里按列类型转换为 int64、uint64、float64 或 string；
+// 二进制类型保留 []byte 副本。
+func normalizeValue(value interface{}, ct *sql.ColumnType) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case float32:
+		return float64(v)
+	case []byte:
+		typeName := strings.ToUpper(ct.DatabaseTypeName())
+		s := string(v)
+		switch strings.TrimPrefix(typeName, "UNSIGNED ") {
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n
+			}
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return n
+			}
+		case "DECIMAL", "FLOAT", "DOUBLE":
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY", "BIT":
+			return append([]byte(nil), v...)
+		}
+		return s
+	}
+	return value
+}
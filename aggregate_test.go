@@ -0,0 +1,143 @@
+package grds
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestAggregateSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+		vars []interface{}
+	}{
+		{"multiple aggregations", func() error {
+			_, err := c.Table("orders").WhereEq("status", 1).Aggregate(Sum("amount"), Count("*"), Max("o.created_at").As("last"))
+			return err
+		}, "SELECT SUM(`amount`) AS `sum_amount`, COUNT(*) AS `count`, MAX(`o`.`created_at`) AS `last` FROM `orders` WHERE `status` = ?",
+			[]interface{}{1}},
+		{"count distinct", func() error {
+			_, err := c.Table("orders").Aggregate(CountDistinct("user_id"), Avg("amount"), Min("amount"))
+			return err
+		}, "SELECT COUNT(DISTINCT `user_id`) AS `count_distinct_user_id`, AVG(`amount`) AS `avg_amount`, MIN(`amount`) AS `min_amount` FROM `orders`",
+			nil},
+		{"grouped", func() error {
+			_, err := c.Table("orders").GroupAggregate([]string{"status", "o.user_id"}, Sum("amount"))
+			return err
+		}, "SELECT `status`, `o`.`user_id`, SUM(`amount`) AS `sum_amount` FROM `orders` GROUP BY `status`, `o`.`user_id`",
+			nil},
+		{"sum helper", func() error {
+			_, err := c.Table("orders").Sum("amount")
+			return err
+		}, "SELECT SUM(`amount`) AS `result` FROM `orders`", nil},
+		{"max helper", func() error {
+			_, err := c.Table("orders").Max("created_at")
+			return err
+		}, "SELECT MAX(`created_at`) AS `result` FROM `orders`", nil},
+		{"ignores order and page", func() error {
+			_, err := c.Table("orders").OrderByDesc("id").Page(3, 10).Sum("amount")
+			return err
+		}, "SELECT SUM(`amount`) AS `result` FROM `orders`", nil},
+		{"grouped keeps order and limit", func() error {
+			_, err := c.Table("orders").OrderByDesc("status").Limit(5).GroupAggregate([]string{"status"}, Count("*"))
+			return err
+		}, "SELECT `status`, COUNT(*) AS `count` FROM `orders` GROUP BY `status` ORDER BY `status` DESC LIMIT 5",
+			nil},
+	}
+	for _, tt := range tests {
+		sql, vars := c.lastSQL(t, tt.run())
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestAggregateInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		run  func() error
+		err  string
+	}{
+		{"no aggregation", func() error {
+			_, err := c.Table("orders").Aggregate()
+			return err
+		}, "at least one aggregation"},
+		{"no group columns", func() error {
+			_, err := c.Table("orders").GroupAggregate(nil, Count("*"))
+			return err
+		}, "group columns are required"},
+		{"unsupported function", func() error {
+			_, err := c.Table("orders").Aggregate(Aggregation{Func: "STDDEV", Column: "amount"})
+			return err
+		}, `unsupported aggregate function "STDDEV"`},
+		{"invalid column", func() error {
+			_, err := c.Table("orders").Aggregate(Sum("amount)"))
+			return err
+		}, "invalid identifier"},
+		{"sum of star", func() error {
+			_, err := c.Table("orders").Aggregate(Sum("*"))
+			return err
+		}, "invalid identifier"},
+		{"invalid alias", func() error {
+			_, err := c.Table("orders").Aggregate(Count("*").As("a.b"))
+			return err
+		}, "invalid identifier"},
+		{"invalid group column", func() error {
+			_, err := c.Table("orders").GroupAggregate([]string{"status;"}, Count("*"))
+			return err
+		}, "invalid identifier"},
+	}
+	for _, tt := range tests {
+		err := tt.run()
+		if err == nil || errors.Is(err, gorm.ErrDryRunModeUnsupported) || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Op != "aggregate" || e.Table != "orders" {
+			t.Errorf("%s: error = %#v, want *Error for aggregate on orders", tt.name, err)
+		}
+	}
+}
+
+func TestAggregateResult(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)
+	res := &AggregateResult{values: map[string]interface{}{
+		"count": int64(3),
+		"sum":   float64(2.5),
+		"max":   "2024-05-01 08:00:00",
+		"name":  []byte("tom"),
+		"last":  created,
+		"none":  nil,
+	}}
+	if res.Int64("count") != 3 || res.Float64("count") != 3 || res.Int64("sum") != 2 || res.Float64("sum") != 2.5 {
+		t.Errorf("numbers: %d %v %d %v", res.Int64("count"), res.Float64("count"), res.Int64("sum"), res.Float64("sum"))
+	}
+	if !res.Time("max").Equal(created) || !res.Time("last").Equal(created) || !res.Time("none").IsZero() {
+		t.Errorf("times: %v %v %v", res.Time("max"), res.Time("last"), res.Time("none"))
+	}
+	if res.String("name") != "tom" || res.String("last") != "2024-05-01 08:00:00" || res.String("none") != "" {
+		t.Errorf("strings: %q %q %q", res.String("name"), res.String("last"), res.String("none"))
+	}
+	if !res.IsNull("none") || !res.IsNull("missing") || res.IsNull("count") {
+		t.Error("IsNull mismatch")
+	}
+
+	groups := GroupedResults{
+		{AggregateResult: res, Keys: []interface{}{int64(1), nil}},
+		{AggregateResult: res, Keys: []interface{}{[]byte("2"), "a"}},
+	}
+	if groups.Lookup(1, nil) != groups[0] || groups.Lookup("2", "a") != groups[1] || groups.Lookup(3, nil) != nil {
+		t.Error("Lookup mismatch")
+	}
+	if m := groups.Map(); m["1|NULL"] != groups[0] || m["2|a"] != groups[1] {
+		t.Errorf("Map keys = %v", m)
+	}
+}
@@ -0,0 +1,693 @@
+// Package audit 行级审计日志，记录谁在什么时候修改了哪些行的哪些列
+//
+// 作为 gorm 插件注册在创建、更新、删除回调上：更新和删除前先用相同的条件锁定并读取受影响的行，
+// 执行后对比得到每列的旧值和新值，与修改在同一个事务中写入 grds_audit_logs 表或自定义 Sink。
+// 写入审计失败时修改会随事务回滚。
+//
+//	cfg := grds.DefaultConfig().WithPlugin(audit.New(&audit.Options{
+//	    Tables:         []string{"users", "orders"},
+//	    ExcludeColumns: []string{"password_hash", "users.api_token"},
+//	}))
+//	client, err := grds.NewClient(cfg)
+//
+//	ctx = audit.WithActor(ctx, "user:42")
+//	grds.WithContext(ctx).Table("users").Where("id = ?", 1).Update("email", "a@example.com")
+//
+// 开启 SkipDefaultTransaction 且不在事务中时，审计记录与修改不保证原子性。
+// 更新修改主键且新主键由表达式计算时无法对应修改前后的行，更新返回错误并回滚。
+// Raw/Exec 执行的语句不会被审计。
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nicexiaonie/grds"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultTable 默认审计表名
+const DefaultTable = "grds_audit_logs"
+
+// Operation 修改类型
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+const (
+	skipKey     = "grds:audit_skip"     // 写入审计记录的会话，不再审计
+	snapshotKey = "grds:audit_snapshot" // 更新和删除前读取的行
+)
+
+// Change 一列的旧值和新值，创建时 Old 为 nil，删除时 New 为 nil
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Changes 列名到变化的映射，以 JSON 保存
+type Changes map[string]Change
+
+// Value 实现 driver.Valuer
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+// Scan 实现 sql.Scanner
+func (c *Changes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("audit: cannot scan %T into Changes", value)
+}
+
+// Entry 审计记录
+type Entry struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement"`
+	Table      string    `gorm:"column:table_name;size:64;not null;index:idx_audit_record,priority:1"`
+	PrimaryKey string    `gorm:"size:191;not null;default:'';index:idx_audit_record,priority:2"`
+	Operation  Operation `gorm:"size:16;not null"`
+	Changes    Changes   `gorm:"type:json"`
+	Actor      string    `gorm:"size:191;not null;default:'';index"`
+	CreatedAt  time.Time `gorm:"type:datetime(6);not null;index"`
+}
+
+// TableName 默认表名
+func (Entry) TableName() string {
+	return DefaultTable
+}
+
+// Sink 审计记录的输出，tx 与被审计的修改处于同一个事务中
+type Sink interface {
+	Write(tx *gorm.DB, entries []*Entry) error
+}
+
+// SinkFunc 函数形式的 Sink
+type SinkFunc func(tx *gorm.DB, entries []*Entry) error
+
+// Write 实现 Sink
+func (f SinkFunc) Write(tx *gorm.DB, entries []*Entry) error {
+	return f(tx, entries)
+}
+
+// tableSink 写入审计表
+type tableSink struct {
+	table string
+}
+
+// Write 批量插入审计表
+func (s tableSink) Write(tx *gorm.DB, entries []*Entry) error {
+	return tx.Table(s.table).Create(&entries).Error
+}
+
+// actorContextKey 上下文中的操作人
+type actorContextKey struct{}
+
+// WithActor 返回带有操作人的上下文
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext 返回上下文中的操作人
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// Options 审计选项
+type Options struct {
+	Tables         []string                         // 审计的表，为空时审计除审计表外的所有表
+	ExcludeColumns []string                         // 不记录的列，"column" 作用于所有表，"table.column" 只作用于该表
+	Table          string                           // 审计表名，默认 grds_audit_logs
+	Sink           Sink                             // 自定义输出，默认写入审计表
+	Actor          func(ctx context.Context) string // 获取操作人，默认使用 WithActor 设置的值
+	PrimaryKey     string                           // 没有模型时的主键列，默认 id
+	SkipMigrate    bool                             // 不自动创建审计表
+}
+
+// Plugin 审计插件
+type Plugin struct {
+	opts    Options
+	sink    Sink
+	tables  map[string]bool
+	exclude map[string]bool
+}
+
+// New 创建审计插件，opts 为 nil 时审计所有表
+func New(opts *Options) *Plugin {
+	p := &Plugin{tables: map[string]bool{}, exclude: map[string]bool{}}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Table == "" {
+		p.opts.Table = DefaultTable
+	}
+	if p.opts.Actor == nil {
+		p.opts.Actor = ActorFromContext
+	}
+	if p.opts.PrimaryKey == "" {
+		p.opts.PrimaryKey = "id"
+	}
+	p.sink = p.opts.Sink
+	if p.sink == nil {
+		p.sink = tableSink{table: p.opts.Table}
+	}
+	for _, table := range p.opts.Tables {
+		p.tables[table] = true
+	}
+	for _, column := range p.opts.ExcludeColumns {
+		p.exclude[column] = true
+	}
+	return p
+}
+
+// Name 实现 gorm.Plugin
+func (p *Plugin) Name() string {
+	return "grds:audit"
+}
+
+// Initialize 实现 gorm.Plugin，默认自动创建审计表并注册回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if !grds.ValidIdentifier(p.opts.Table) {
+		return fmt.Errorf("audit: %w: %q", grds.ErrInvalidIdentifier, p.opts.Table)
+	}
+	if p.opts.Sink == nil && !p.opts.SkipMigrate {
+		if err := db.Table(p.opts.Table).AutoMigrate(&Entry{}); err != nil {
+			return fmt.Errorf("audit: migrate %s: %w", p.opts.Table, err)
+		}
+	}
+
+	// 审计记录要在提交前写入，After 回调需限定在 gorm:commit_or_rollback_transaction 之前
+	cb := db.Callback()
+	const commit = "gorm:commit_or_rollback_transaction"
+	if err := cb.Create().After("gorm:create").Before(commit).Register("grds:audit_after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("grds:audit_before_update", p.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Before(commit).Register("grds:audit_after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("grds:audit_before_delete", p.before); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Before(commit).Register("grds:audit_after_delete", p.afterDelete)
+}
+
+// audited 语句是否需要审计
+func (p *Plugin) audited(db *gorm.DB) bool {
+	table := tableName(db.Statement)
+	if db.Error != nil || db.DryRun || table == "" || table == p.opts.Table {
+		return false
+	}
+	if skip, ok := db.Get(skipKey); ok && skip == true {
+		return false
+	}
+	return len(p.tables) == 0 || p.tables[table]
+}
+
+// tableName 语句修改的表名，Table("users u") 时 stmt.Table 为别名，从表达式中取表名
+func tableName(stmt *gorm.Statement) string {
+	if stmt.TableExpr != nil {
+		if fields := strings.Fields(stmt.TableExpr.SQL); len(fields) > 0 {
+			return strings.ReplaceAll(fields[0], "`", "")
+		}
+	}
+	return stmt.Table
+}
+
+// primaryKeys 主键列
+func (p *Plugin) primaryKeys(stmt *gorm.Statement) []string {
+	if stmt.Schema != nil && len(stmt.Schema.PrimaryFieldDBNames) > 0 {
+		return stmt.Schema.PrimaryFieldDBNames
+	}
+	return []string{p.opts.PrimaryKey}
+}
+
+// row 一行数据，列名到值
+type row map[string]interface{}
+
+// snapshot 修改前读取的行
+type snapshot struct {
+	keys []string       // 按读取顺序的主键
+	rows map[string]row // 主键到行
+}
+
+// before 更新和删除前按语句的条件锁定并读取受影响的行
+func (p *Plugin) before(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+	exprs := whereExprs(stmt)
+	if stmt.Schema != nil {
+		// gorm 在执行时才按模型的主键值限定范围，这里提前加上
+		exprs = append(exprs, identityConditions(stmt, stmt.ReflectValue)...)
+		if model := reflect.ValueOf(stmt.Model); stmt.Model != nil && stmt.Dest != stmt.Model && !sameValue(model, stmt.ReflectValue) {
+			exprs = append(exprs, identityConditions(stmt, model)...)
+		}
+	}
+	if len(exprs) == 0 && !stmt.AllowGlobalUpdate {
+		// gorm 会以 ErrMissingWhereClause 拒绝执行
+		return
+	}
+
+	tx := p.selectSession(db)
+	if len(exprs) > 0 {
+		tx = tx.Clauses(clause.Where{Exprs: exprs})
+	}
+	for _, name := range []string{"ORDER BY", "LIMIT"} {
+		if c, ok := stmt.Clauses[name]; ok {
+			tx = tx.Clauses(c.Expression)
+		}
+	}
+	snap, err := p.read(tx.Clauses(clause.Locking{Strength: "UPDATE"}), p.primaryKeys(stmt))
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read %s: %w", tableName(stmt), err))
+		return
+	}
+	db.InstanceSet(snapshotKey, snap)
+}
+
+// afterUpdate 按主键重新读取更新后的行，记录有变化的列
+func (p *Plugin) afterUpdate(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	before, ok := p.snapshot(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	table := tableName(stmt)
+	pks := p.primaryKeys(stmt)
+	rows := make([]row, 0, len(before.keys))
+	for _, key := range before.keys {
+		rows = append(rows, before.rows[key])
+	}
+	after, err := p.readByKeys(db, pks, rows)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read %s: %w", table, err))
+		return
+	}
+	newKeys, err := p.movedRows(db, pks, before, after)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read %s: %w", table, err))
+		return
+	}
+
+	entries := make([]*Entry, 0, len(before.keys))
+	for _, key := range before.keys {
+		newKey := newKeys[key]
+		newRow := after.rows[newKey]
+		changes := Changes{}
+		for column, old := range before.rows[key] {
+			if value := newRow[column]; !reflect.DeepEqual(old, value) && !p.excluded(table, column) {
+				changes[column] = Change{Old: old, New: value}
+			}
+		}
+		if len(changes) > 0 {
+			entries = append(entries, p.entry(db, OperationUpdate, newKey, changes))
+		}
+	}
+	p.write(db, entries)
+}
+
+// movedRows 返回修改前的主键到修改后主键的映射
+//
+// 更新修改了主键时按旧主键读不到这些行，从 SET 子句取出新的主键值再读取一次；
+// 新主键由表达式计算或读取不到时返回错误，使修改回滚，不会漏记。
+func (p *Plugin) movedRows(db *gorm.DB, pks []string, before, after *snapshot) (map[string]string, error) {
+	newKeys := make(map[string]string, len(before.keys))
+	var missing []string
+	for _, key := range before.keys {
+		if _, ok := after.rows[key]; ok {
+			newKeys[key] = key
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return newKeys, nil
+	}
+
+	assigned, ok := primaryKeyAssignments(db.Statement, pks)
+	if !ok {
+		return nil, fmt.Errorf("primary key of rows %s changed and cannot be tracked", strings.Join(missing, "; "))
+	}
+	moved := make([]row, 0, len(missing))
+	for _, key := range missing {
+		r := row{}
+		for _, pk := range pks {
+			r[pk] = before.rows[key][pk]
+			if value, ok := assigned[pk]; ok {
+				r[pk] = value
+			}
+		}
+		newKeys[key] = rowKey(r, pks)
+		moved = append(moved, r)
+	}
+	snap, err := p.readByKeys(db, pks, moved)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range missing {
+		r, ok := snap.rows[newKeys[key]]
+		if !ok {
+			return nil, fmt.Errorf("row %s not found after update", key)
+		}
+		after.rows[newKeys[key]] = r
+	}
+	return newKeys, nil
+}
+
+// primaryKeyAssignments 从 SET 子句中取出主键的新值，值为表达式时返回 false
+func primaryKeyAssignments(stmt *gorm.Statement, pks []string) (map[string]interface{}, bool) {
+	c, ok := stmt.Clauses["SET"]
+	if !ok {
+		return nil, false
+	}
+	set, ok := c.Expression.(clause.Set)
+	if !ok {
+		return nil, false
+	}
+	assigned := map[string]interface{}{}
+	for _, a := range set {
+		for _, pk := range pks {
+			if a.Column.Name != pk {
+				continue
+			}
+			switch a.Value.(type) {
+			case clause.Expression, *gorm.DB:
+				return nil, false
+			}
+			assigned[pk] = normalize(a.Value)
+		}
+	}
+	return assigned, len(assigned) > 0
+}
+
+// afterDelete 记录被删除行的所有列
+func (p *Plugin) afterDelete(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	before, ok := p.snapshot(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+	entries := make([]*Entry, 0, len(before.keys))
+	for _, key := range before.keys {
+		changes := Changes{}
+		for column, old := range before.rows[key] {
+			if !p.excluded(tableName(db.Statement), column) {
+				changes[column] = Change{Old: old}
+			}
+		}
+		entries = append(entries, p.entry(db, OperationDelete, key, changes))
+	}
+	p.write(db, entries)
+}
+
+// afterCreate 记录插入的值，自增主键在插入后已回填
+func (p *Plugin) afterCreate(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+	pks := p.primaryKeys(stmt)
+	var entries []*Entry
+	add := func(r row) {
+		changes := Changes{}
+		for column, value := range r {
+			if !p.excluded(tableName(stmt), column) {
+				changes[column] = Change{New: value}
+			}
+		}
+		entries = append(entries, p.entry(db, OperationCreate, rowKey(r, pks), changes))
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		add(p.mapRow(stmt, dest))
+	case *map[string]interface{}:
+		add(p.mapRow(stmt, *dest))
+	case []map[string]interface{}:
+		for _, m := range dest {
+			add(p.mapRow(stmt, m))
+		}
+	case *[]map[string]interface{}:
+		for _, m := range *dest {
+			add(p.mapRow(stmt, m))
+		}
+	default:
+		if stmt.Schema == nil {
+			return
+		}
+		switch stmt.ReflectValue.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < stmt.ReflectValue.Len(); i++ {
+				add(p.structRow(stmt, reflect.Indirect(stmt.ReflectValue.Index(i))))
+			}
+		case reflect.Struct:
+			add(p.structRow(stmt, stmt.ReflectValue))
+		}
+	}
+	p.write(db, entries)
+}
+
+// structRow 读取结构体中会被插入的列
+func (p *Plugin) structRow(stmt *gorm.Statement, rv reflect.Value) row {
+	selected, restricted := stmt.SelectAndOmitColumns(true, false)
+	r := row{}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if v, ok := selected[field.DBName]; (ok && !v) || (!ok && restricted) {
+			continue
+		}
+		value, _ := field.ValueOf(stmt.Context, rv)
+		r[field.DBName] = normalize(value)
+	}
+	return r
+}
+
+// mapRow 把 map 的键转换为列名
+func (p *Plugin) mapRow(stmt *gorm.Statement, m map[string]interface{}) row {
+	r := make(row, len(m))
+	for key, value := range m {
+		if strings.HasPrefix(key, "@") {
+			continue
+		}
+		column := key
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(key); field != nil && field.DBName != "" {
+				column = field.DBName
+			}
+		}
+		r[column] = normalize(value)
+	}
+	return r
+}
+
+// selectSession 与当前语句共用连接（事务）的查询会话
+func (p *Plugin) selectSession(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	tx := db.Session(&gorm.Session{NewDB: true, SkipDefaultTransaction: true})
+	if stmt.Schema != nil {
+		tx = tx.Model(reflect.New(stmt.Schema.ModelType).Interface())
+	}
+	// 沿用语句的表达式，Table("users u") 时条件中的别名仍然有效
+	if stmt.TableExpr != nil {
+		tx = tx.Table(stmt.TableExpr.SQL, stmt.TableExpr.Vars...)
+	} else {
+		tx = tx.Table(stmt.Table)
+	}
+	tx = tx.Set(skipKey, true)
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+	return tx
+}
+
+// readByKeys 不加软删除条件，按行中的主键值读取
+func (p *Plugin) readByKeys(db *gorm.DB, pks []string, rows []row) (*snapshot, error) {
+	values := make([]interface{}, 0, len(rows))
+	for _, r := range rows {
+		if len(pks) == 1 {
+			values = append(values, r[pks[0]])
+			continue
+		}
+		tuple := make([]interface{}, len(pks))
+		for i, pk := range pks {
+			tuple[i] = r[pk]
+		}
+		values = append(values, tuple)
+	}
+	var column interface{} = clause.Column{Table: clause.CurrentTable, Name: pks[0]}
+	if len(pks) > 1 {
+		columns := make([]clause.Column, len(pks))
+		for i, pk := range pks {
+			columns[i] = clause.Column{Table: clause.CurrentTable, Name: pk}
+		}
+		column = columns
+	}
+	return p.read(p.selectSession(db).Unscoped().Clauses(clause.Where{
+		Exprs: []clause.Expression{clause.IN{Column: column, Values: values}},
+	}), pks)
+}
+
+// read 读取所有列，按主键索引
+func (p *Plugin) read(tx *gorm.DB, pks []string) (*snapshot, error) {
+	rows, err := tx.Select("*").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{rows: map[string]row{}}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		r := make(row, len(columns))
+		for i, column := range columns {
+			r[column] = normalize(values[i])
+		}
+		key := rowKey(r, pks)
+		if _, ok := snap.rows[key]; !ok {
+			snap.keys = append(snap.keys, key)
+		}
+		snap.rows[key] = r
+	}
+	return snap, rows.Err()
+}
+
+// snapshot 取出修改前读取的行
+func (p *Plugin) snapshot(db *gorm.DB) (*snapshot, bool) {
+	value, ok := db.InstanceGet(snapshotKey)
+	if !ok {
+		return nil, false
+	}
+	snap := value.(*snapshot)
+	return snap, len(snap.keys) > 0
+}
+
+// entry 创建审计记录
+func (p *Plugin) entry(db *gorm.DB, op Operation, key string, changes Changes) *Entry {
+	return &Entry{
+		Table:      tableName(db.Statement),
+		PrimaryKey: key,
+		Operation:  op,
+		Changes:    changes,
+		Actor:      p.opts.Actor(db.Statement.Context),
+		CreatedAt:  time.Now(),
+	}
+}
+
+// write 在当前连接（事务）中写出审计记录，失败时记录错误使事务回滚
+func (p *Plugin) write(db *gorm.DB, entries []*Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	tx := db.Session(&gorm.Session{NewDB: true, SkipDefaultTransaction: true, SkipHooks: true}).Set(skipKey, true)
+	if err := p.sink.Write(tx, entries); err != nil {
+		_ = db.AddError(fmt.Errorf("audit: write %s: %w", tableName(db.Statement), err))
+	}
+}
+
+// excluded 列是否不记录
+func (p *Plugin) excluded(table, column string) bool {
+	return p.exclude[column] || p.exclude[table+"."+column]
+}
+
+// whereExprs 复制语句已有的 WHERE 条件
+func whereExprs(stmt *gorm.Statement) []clause.Expression {
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			return append([]clause.Expression{}, where.Exprs...)
+		}
+	}
+	return nil
+}
+
+// identityConditions 按模型中非零的主键值生成条件
+func identityConditions(stmt *gorm.Statement, rv reflect.Value) []clause.Expression {
+	if !rv.IsValid() {
+		return nil
+	}
+	_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, reflect.Indirect(rv), stmt.Schema.PrimaryFields)
+	column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+	if len(values) == 0 {
+		return nil
+	}
+	return []clause.Expression{clause.IN{Column: column, Values: values}}
+}
+
+// sameValue model 是否指向 rv（以 map 更新时 ReflectValue 即为 Model）
+func sameValue(model, rv reflect.Value) bool {
+	return model.Kind() == reflect.Ptr && rv.CanAddr() && model.Pointer() == rv.Addr().Pointer()
+}
+
+// rowKey 主键值，复合主键用逗号连接
+func rowKey(r row, pks []string) string {
+	parts := make([]string, len(pks))
+	for i, pk := range pks {
+		if value := r[pk]; value != nil {
+			parts[i] = fmt.Sprint(value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// normalize 转换为便于比较和 JSON 编码的值
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v
+	case driver.Valuer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		dv, err := v.Value()
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return normalize(dv)
+	}
+	return value
+}
@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nicexiaonie/grds/internal/dbtest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestTableName(t *testing.T) {
+	db, _ := dbtest.Open(t)
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"users", "users"},
+		{"users u", "users"},
+		{"users AS u", "users"},
+		{"`users` `u`", "users"},
+	}
+	for _, tt := range tests {
+		if got := tableName(db.Table(tt.table).Statement); got != tt.want {
+			t.Errorf("tableName(Table(%q)) = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestAuditedAlias(t *testing.T) {
+	p := New(&Options{Tables: []string{"users"}})
+	db, _ := dbtest.Open(t)
+	db = db.Session(&gorm.Session{NewDB: true})
+	// audited 跳过 DryRun，这里只检查表名匹配
+	db.DryRun = false
+
+	tests := []struct {
+		table string
+		want  bool
+	}{
+		{"users", true},
+		{"users u", true},
+		{"orders", false},
+		{"orders users", false},
+		{DefaultTable, false},
+	}
+	for _, tt := range tests {
+		if got := p.audited(db.Table(tt.table)); got != tt.want {
+			t.Errorf("audited(Table(%q)) = %v, want %v", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestSelectSessionAlias(t *testing.T) {
+	p := New(nil)
+	db, _ := dbtest.Open(t)
+	db = db.Table("users u").Where("u.status = ?", 1)
+
+	tx := p.selectSession(db).Clauses(clause.Where{Exprs: whereExprs(db.Statement)}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).Select("*").Find(&[]map[string]interface{}{})
+	want := "SELECT * FROM users u WHERE u.status = ? FOR UPDATE"
+	if got := tx.Statement.SQL.String(); got != want {
+		t.Errorf("snapshot sql = %q, want %q", got, want)
+	}
+
+	tx = p.selectSession(db.Session(&gorm.Session{NewDB: true}).Table("users")).Select("*").Find(&[]map[string]interface{}{})
+	if got, want := tx.Statement.SQL.String(), "SELECT * FROM `users`"; got != want {
+		t.Errorf("snapshot sql = %q, want %q", got, want)
+	}
+}
+
+func TestReadByKeysSQL(t *testing.T) {
+	p := New(nil)
+	db, rec := dbtest.Open(t)
+	db = db.Table("users u")
+
+	tests := []struct {
+		pks  []string
+		rows []row
+		sql  string
+		vars []interface{}
+	}{
+		{
+			[]string{"id"},
+			[]row{{"id": int64(1)}, {"id": int64(2)}},
+			"SELECT * FROM users u WHERE `u`.`id` IN (?,?)",
+			[]interface{}{int64(1), int64(2)},
+		},
+		{
+			[]string{"tenant_id", "id"},
+			[]row{{"tenant_id": int64(7), "id": int64(1)}},
+			"SELECT * FROM users u WHERE (`u`.`tenant_id`,`u`.`id`) IN ((?,?))",
+			[]interface{}{int64(7), int64(1)},
+		},
+	}
+	for _, tt := range tests {
+		// Rows 在 DryRun 下不执行，生成的 SQL 由 rec 记录
+		if _, err := p.readByKeys(db, tt.pks, tt.rows); err != gorm.ErrDryRunModeUnsupported {
+			t.Fatalf("readByKeys: %v", err)
+		}
+		stmt, _ := rec.Last()
+		if stmt.SQL != tt.sql || !reflect.DeepEqual(stmt.Vars, tt.vars) {
+			t.Errorf("readByKeys(%v) = %q %v, want %q %v", tt.pks, stmt.SQL, stmt.Vars, tt.sql, tt.vars)
+		}
+	}
+}
+
+func TestPrimaryKeyAssignments(t *testing.T) {
+	tests := []struct {
+		name string
+		set  interface{}
+		want map[string]interface{}
+		ok   bool
+	}{
+		{"no set", nil, nil, false},
+		{"key unchanged", clause.Set{{Column: clause.Column{Name: "email"}, Value: "a@example.com"}}, nil, false},
+		{"key assigned", clause.Set{
+			{Column: clause.Column{Name: "id"}, Value: 9},
+			{Column: clause.Column{Name: "email"}, Value: "a@example.com"},
+		}, map[string]interface{}{"id": 9}, true},
+		{"key bytes", clause.Set{{Column: clause.Column{Name: "id"}, Value: []byte("k9")}}, map[string]interface{}{"id": "k9"}, true},
+		{"key expression", clause.Set{{Column: clause.Column{Name: "id"}, Value: gorm.Expr("id + ?", 1)}}, nil, false},
+	}
+	for _, tt := range tests {
+		stmt := &gorm.Statement{Clauses: map[string]clause.Clause{}}
+		if tt.set != nil {
+			stmt.Clauses["SET"] = clause.Clause{Name: "SET", Expression: tt.set.(clause.Set)}
+		}
+		got, ok := primaryKeyAssignments(stmt, []string{"id"})
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: primaryKeyAssignments = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	p := New(&Options{ExcludeColumns: []string{"password_hash", "users.api_token"}})
+	tests := []struct {
+		table, column string
+		want          bool
+	}{
+		{"users", "password_hash", true},
+		{"orders", "password_hash", true},
+		{"users", "api_token", true},
+		{"orders", "api_token", false},
+		{"users", "email", false},
+	}
+	for _, tt := range tests {
+		if got := p.excluded(tt.table, tt.column); got != tt.want {
+			t.Errorf("excluded(%q, %q) = %v, want %v", tt.table, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestChangesValueScan(t *testing.T) {
+	changes := Changes{"email": {Old: "a@example.com", New: "b@example.com"}}
+	value, err := changes.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"email":{"old":"a@example.com","new":"b@example.com"}}`; value != want {
+		t.Errorf("Value() = %v, want %s", value, want)
+	}
+	if value, _ := Changes(nil).Value(); value != "{}" {
+		t.Errorf("nil Value() = %v, want {}", value)
+	}
+
+	for _, src := range []interface{}{value, []byte(value.(string))} {
+		var got Changes
+		if err := got.Scan(src); err != nil || !reflect.DeepEqual(got, changes) {
+			t.Errorf("Scan(%T) = %v, %v", src, got, err)
+		}
+	}
+	var got Changes
+	if err := got.Scan(1); err == nil {
+		t.Error("Scan(int) should fail")
+	}
+}
@@ -0,0 +1,248 @@
+package grds
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultBatchUpdateSize BatchUpdate 默认每批记录数
+const DefaultBatchUpdateSize = 500
+
+// batchRow 批量更新中的一行：键值及各列的新值
+type batchRow struct {
+	key    interface{}
+	values []interface{}
+}
+
+// BatchUpdate 按键列批量更新，每条记录可以有不同的值
+//
+// records 为结构体切片或 []map[string]interface{}，每批编译为一条
+// UPDATE ... SET col = CASE key WHEN ? THEN ? ... END WHERE key IN (...)。
+// columns 为空时，结构体更新除键列、主键和创建时间外的全部字段，map 更新其全部键。
+// 返回所有批次实际影响的总行数。
+func (qb *QueryBuilder) BatchUpdate(records interface{}, keyColumn string, columns ...string) (int64, error) {
+	return qb.BatchUpdateInBatches(records, keyColumn, DefaultBatchUpdateSize, columns...)
+}
+
+// BatchUpdateInBatches 按指定批大小批量更新，语义同 BatchUpdate
+func (qb *QueryBuilder) BatchUpdateInBatches(records interface{}, keyColumn string, batchSize int, columns ...string) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchUpdateSize
+	}
+
+	rows, keyColumn, columns, model, err := qb.collectBatchRows(records, keyColumn, columns)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 || len(columns) == 0 {
+		return 0, nil
+	}
+	for _, column := range append([]string{keyColumn}, columns...) {
+		if !ValidIdentifier(column) {
+			return 0, fmt.Errorf("batch update: %w: %q", ErrInvalidIdentifier, column)
+		}
+	}
+
+	base := qb.db
+	if base.Statement.Model == nil && base.Statement.Table == "" && model != nil {
+		base = base.Model(model)
+	}
+
+	var affected int64
+	run := func(tx *gorm.DB) error {
+		for start := 0; start < len(rows); start += batchSize {
+			end := start + batchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			n, err := execBatchUpdate(tx.Session(&gorm.Session{}), rows[start:end], keyColumn, columns)
+			if err != nil {
+				return err
+			}
+			affected += n
+		}
+		return nil
+	}
+
+	if !base.SkipDefaultTransaction && len(rows) > batchSize {
+		err = base.Transaction(run)
+	} else {
+		err = run(base)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// execBatchUpdate 执行单批 CASE WHEN 更新
+func execBatchUpdate(tx *gorm.DB, rows []batchRow, keyColumn string, columns []string) (int64, error) {
+	quotedKey := tx.Statement.Quote(keyColumn)
+
+	keys := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.key)
+	}
+
+	values := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		quotedColumn := tx.Statement.Quote(column)
+
+		var sql strings.Builder
+		vars := make([]interface{}, 0, len(rows)*2)
+		sql.WriteString("CASE ")
+		sql.WriteString(quotedKey)
+		for _, row := range rows {
+			sql.WriteString(" WHEN ? THEN ?")
+			vars = append(vars, row.key, row.values[i])
+		}
+		sql.WriteString(" ELSE ")
+		sql.WriteString(quotedColumn)
+		sql.WriteString(" END")
+
+		values[column] = clause.Expr{SQL: sql.String(), Vars: vars}
+	}
+
+	result := tx.Where(quotedKey+" IN ?", keys).Updates(values)
+	return result.RowsAffected, wrapError("batch_update", result)
+}
+
+// collectBatchRows 从记录切片中提取键值和待更新列的值
+//
+// 返回的键列和更新列均为数据库列名；结构体记录同时返回其模型，用于推断表名
+func (qb *QueryBuilder) collectBatchRows(records interface{}, keyColumn string, columns []string) ([]batchRow, string, []string, interface{}, error) {
+	if keyColumn == "" {
+		return nil, "", nil, nil, fmt.Errorf("batch update: key column is required")
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(records))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, "", nil, nil, fmt.Errorf("batch update: records must be a slice, got %T", records)
+	}
+	if rv.Len() == 0 {
+		return nil, keyColumn, columns, nil, nil
+	}
+
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	switch elemType.Kind() {
+	case reflect.Map:
+		rows, columns, err := collectMapRows(rv, keyColumn, columns)
+		return rows, keyColumn, columns, nil, err
+	case reflect.Struct:
+		model := reflect.New(elemType).Interface()
+		stmt := &gorm.Statement{DB: qb.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, "", nil, nil, fmt.Errorf("batch update: %w", err)
+		}
+		keyField := stmt.Schema.LookUpField(keyColumn)
+		if keyField == nil || keyField.DBName == "" {
+			return nil, "", nil, nil, fmt.Errorf("batch update: key column %q not found in %s", keyColumn, stmt.Schema.Name)
+		}
+		rows, columns, err := collectStructRows(qb.db, stmt.Schema, rv, keyField, columns)
+		return rows, keyField.DBName, columns, model, err
+	}
+	return nil, "", nil, nil, fmt.Errorf("batch update: unsupported record type %s", elemType)
+}
+
+// collectStructRows 提取结构体切片的键值和列值
+func collectStructRows(db *gorm.DB, s *schema.Schema, rv reflect.Value, keyField *schema.Field, columns []string) ([]batchRow, []string, error) {
+	fields := make([]*schema.Field, 0, len(s.Fields))
+	if len(columns) == 0 {
+		for _, field := range s.Fields {
+			if field.DBName == "" || field == keyField || field.PrimaryKey || !field.Updatable || field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 {
+				continue
+			}
+			fields = append(fields, field)
+		}
+	} else {
+		for _, column := range columns {
+			field := s.LookUpField(column)
+			if field == nil || field.DBName == "" {
+				return nil, nil, fmt.Errorf("batch update: column %q not found in %s", column, s.Name)
+			}
+			fields = append(fields, field)
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.DBName)
+	}
+
+	ctx := db.Statement.Context
+	rows := make([]batchRow, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elem := reflect.Indirect(rv.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+
+		key, isZero := keyField.ValueOf(ctx, elem)
+		if isZero {
+			return nil, nil, fmt.Errorf("batch update: record %d has zero value for key column %q", i, keyField.DBName)
+		}
+
+		values := make([]interface{}, 0, len(fields))
+		for _, field := range fields {
+			value, _ := field.ValueOf(ctx, elem)
+			values = append(values, value)
+		}
+		rows = append(rows, batchRow{key: key, values: values})
+	}
+	return rows, names, nil
+}
+
+// collectMapRows 提取 map 切片的键值和列值
+func collectMapRows(rv reflect.Value, keyColumn string, columns []string) ([]batchRow, []string, error) {
+	maps := make([]map[string]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		m, ok := reflect.Indirect(rv.Index(i)).Interface().(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("batch update: record %d must be map[string]interface{}", i)
+		}
+		maps = append(maps, m)
+	}
+
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, m := range maps {
+			for column := range m {
+				if column != keyColumn && !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+
+	rows := make([]batchRow, 0, len(maps))
+	for i, m := range maps {
+		key, ok := m[keyColumn]
+		if !ok || key == nil {
+			return nil, nil, fmt.Errorf("batch update: record %d is missing key column %q", i, keyColumn)
+		}
+
+		values := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			value, ok := m[column]
+			if !ok {
+				// 缺失的列保持原值
+				value = clause.Column{Name: column}
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, batchRow{key: key, values: values})
+	}
+	return rows, columns, nil
+}
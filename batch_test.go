@@ -0,0 +1,103 @@
+package grds
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type batchUser struct {
+	ID   int64
+	Name string
+	Age  int
+}
+
+func TestBatchUpdateSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name    string
+		qb      *QueryBuilder
+		records interface{}
+		key     string
+		columns []string
+		want    string
+		vars    []interface{}
+	}{
+		{"struct default columns", c.Model(&batchUser{}),
+			[]batchUser{{ID: 1, Name: "a", Age: 10}, {ID: 2, Name: "b", Age: 20}}, "id", nil,
+			"UPDATE `batch_users` SET `age`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? ELSE `age` END," +
+				"`name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? ELSE `name` END WHERE `id` IN (?,?)",
+			[]interface{}{int64(1), 10, int64(2), 20, int64(1), "a", int64(2), "b", int64(1), int64(2)}},
+		{"struct pointers infer table", c.Table("batch_users"),
+			[]*batchUser{{ID: 3, Name: "c"}}, "ID", []string{"Name"},
+			"UPDATE `batch_users` SET `name`=CASE `id` WHEN ? THEN ? ELSE `name` END WHERE `id` IN (?)",
+			[]interface{}{int64(3), "c", int64(3)}},
+		{"map keeps missing columns", c.Table("users"),
+			[]map[string]interface{}{{"id": 1, "name": "a", "age": 10}, {"id": 2, "name": "b"}}, "id", nil,
+			"UPDATE `users` SET `age`=CASE `id` WHEN ? THEN ? WHEN ? THEN `age` ELSE `age` END," +
+				"`name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? ELSE `name` END WHERE `id` IN (?,?)",
+			[]interface{}{1, 10, 2, 1, "a", 2, "b", 1, 2}},
+		{"map selected columns", c.Table("users").WhereEq("status", 1),
+			[]map[string]interface{}{{"id": 1, "name": "a", "age": 10}}, "id", []string{"name"},
+			"UPDATE `users` SET `name`=CASE `id` WHEN ? THEN ? ELSE `name` END WHERE `status` = ? AND `id` IN (?)",
+			[]interface{}{1, "a", 1, 1}},
+	}
+	for _, tt := range tests {
+		c.rec.Reset()
+		_, err := tt.qb.BatchUpdate(tt.records, tt.key, tt.columns...)
+		sql, vars := c.lastSQL(t, err)
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestBatchUpdateInBatches(t *testing.T) {
+	c := newTestClient(t)
+	records := []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}}
+	if _, err := c.Table("users").BatchUpdateInBatches(records, "id", 2); err != nil {
+		t.Fatal(err)
+	}
+	statements := c.rec.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("executed %d statements, want 2", len(statements))
+	}
+	if got := statements[1].Vars; !reflect.DeepEqual(got, []interface{}{3, "c", 3}) {
+		t.Errorf("second batch vars = %v", got)
+	}
+
+	c.rec.Reset()
+	if n, err := c.Table("users").BatchUpdate([]map[string]interface{}{}, "id"); n != 0 || err != nil {
+		t.Errorf("empty records = %d, %v", n, err)
+	}
+	if len(c.rec.Statements()) != 0 {
+		t.Error("empty records should not execute")
+	}
+}
+
+func TestBatchUpdateInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name    string
+		records interface{}
+		key     string
+		columns []string
+		err     string
+	}{
+		{"no key", []batchUser{{ID: 1}}, "", nil, "key column is required"},
+		{"not slice", batchUser{ID: 1}, "id", nil, "records must be a slice"},
+		{"unsupported type", []int{1}, "id", nil, "unsupported record type"},
+		{"unknown key", []batchUser{{ID: 1}}, "uid", nil, `key column "uid" not found`},
+		{"unknown column", []batchUser{{ID: 1}}, "id", []string{"email"}, `column "email" not found`},
+		{"zero key", []batchUser{{Name: "a"}}, "id", nil, "zero value for key column"},
+		{"missing key", []map[string]interface{}{{"name": "a"}}, "id", nil, "missing key column"},
+		{"map type", []map[string]string{{"id": "1"}}, "id", nil, "must be map[string]interface{}"},
+		{"invalid column", []map[string]interface{}{{"id": 1, "name;": "a"}}, "id", nil, "invalid identifier"},
+	}
+	for _, tt := range tests {
+		_, err := c.Table("users").BatchUpdate(tt.records, tt.key, tt.columns...)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
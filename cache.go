@@ -0,0 +1,404 @@
+package grds
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultCacheCapacity LRU 缓存默认容量（条目数）
+const DefaultCacheCapacity = 10000
+
+// Cache 查询结果缓存
+//
+// 缓存是尽力而为的：实现无法读写时直接当作未命中即可。ttl <= 0 表示不过期。
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string)
+	Invalidate(ctx context.Context, tags ...string)
+}
+
+// CacheTableTag 表对应的缓存标签，写入该表时自动失效
+func CacheTableTag(table string) string {
+	return "table:" + table
+}
+
+const (
+	cacheTTLKey  = "grds:cache_ttl"
+	cacheTagsKey = "grds:cache_tags"
+)
+
+// Cache 缓存查询结果，Config.Cache 未设置时不生效
+//
+// 缓存键为渲染后的 SQL 和参数，结果自动带上主表的标签，通过 Create/Update/Delete 写入该表时失效。
+// JOIN 其他表时用 tags 附加标签（例如 CacheTableTag("orders")），或调用 Client.InvalidateCache 手动失效。
+// 原生 SQL 和子查询等无法确定主表的查询只使用 tags，没有 tags 时不缓存。
+// 事务中和加锁（FOR UPDATE 等）的查询不使用缓存。
+//
+//	grds.Table("config").WhereEq("group", "site").Cache(time.Minute).Find(&items)
+func (qb *QueryBuilder) Cache(ttl time.Duration, tags ...string) *QueryBuilder {
+	qb.db = qb.db.Set(cacheTTLKey, ttl)
+	if len(tags) > 0 {
+		qb.db = qb.db.Set(cacheTagsKey, tags)
+	}
+	return qb
+}
+
+// InvalidateCache 按标签失效缓存
+func (c *Client) InvalidateCache(ctx context.Context, tags ...string) {
+	if c.cache != nil && len(tags) > 0 {
+		c.cache.invalidate(ctx, tags...)
+	}
+}
+
+// InvalidateTables 失效表的缓存，用于 Exec 等不经过写入回调的修改
+func (c *Client) InvalidateTables(ctx context.Context, tables ...string) {
+	tags := make([]string, len(tables))
+	for i, table := range tables {
+		tags[i] = CacheTableTag(table)
+	}
+	c.InvalidateCache(ctx, tags...)
+}
+
+// queryCache 查询缓存回调
+type queryCache struct {
+	cache Cache
+	epoch uint64 // 每次失效加一，查询期间发生失效时不写入缓存
+}
+
+// lookup 读取缓存并扫描到 Dest，返回是否命中，无法解码的条目当作未命中
+func (qc *queryCache) lookup(db *gorm.DB, key string) bool {
+	data, found := qc.cache.Get(db.Statement.Context, key)
+	if !found {
+		return false
+	}
+	set, err := decodeResultSet(data)
+	if err != nil {
+		return false
+	}
+	set.scan(db)
+	return true
+}
+
+// snapshot 查询前记录失效计数
+func (qc *queryCache) snapshot() uint64 {
+	return atomic.LoadUint64(&qc.epoch)
+}
+
+// store 写入查询返回的原始结果，查询期间发生过失效时放弃写入
+func (qc *queryCache) store(db *gorm.DB, key string, set *resultSet, ttl time.Duration, tags []string, epoch uint64) {
+	if db.Error != nil || atomic.LoadUint64(&qc.epoch) != epoch {
+		return
+	}
+	data, err := encodeResultSet(set)
+	if err != nil {
+		return
+	}
+	qc.cache.Set(db.Statement.Context, key, data, ttl, tags)
+}
+
+// cacheTags 查询结果的标签：主表标签和 Cache 附加的标签，须在构建 SQL 之前调用
+func cacheTags(db *gorm.DB) []string {
+	var tags []string
+	// 原生 SQL 的 Statement.Table 来自 Dest 的模型，不一定是实际查询的表
+	if db.Statement.SQL.Len() == 0 {
+		if table := statementTable(db.Statement); table != "" {
+			tags = append(tags, CacheTableTag(table))
+		}
+	}
+	if extra, ok := db.Get(cacheTagsKey); ok {
+		tags = append(tags, extra.([]string)...)
+	}
+	return tags
+}
+
+// statementTable 语句的主表，Table("users u") 时返回表名而不是别名，子查询等无法确定时返回空
+func statementTable(stmt *gorm.Statement) string {
+	if stmt.TableExpr == nil {
+		return stmt.Table
+	}
+	fields := strings.Fields(stmt.TableExpr.SQL)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+		fields = fields[:2]
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return ""
+	}
+	table := strings.ReplaceAll(fields[0], "`", "")
+	if !ValidIdentifier(table) {
+		return ""
+	}
+	return table
+}
+
+// invalidateWrite 写入后失效表的缓存
+//
+// 事务中的写入除立即失效外，在事务提交后再失效一次，避免提交前并发读取到的旧数据留在缓存中。
+func (qc *queryCache) invalidateWrite(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	table := statementTable(db.Statement)
+	if table == "" {
+		return
+	}
+	tag := CacheTableTag(table)
+	qc.invalidate(db.Statement.Context, tag)
+	if tx := unwrapCacheTx(db.Statement.ConnPool); tx != nil {
+		tx.add(tag)
+	}
+}
+
+// invalidate 失效标签
+func (qc *queryCache) invalidate(ctx context.Context, tags ...string) {
+	atomic.AddUint64(&qc.epoch, 1)
+	qc.cache.Invalidate(ctx, tags...)
+}
+
+// registerCache 注册写入后失效缓存的回调，并包装连接池以便在事务提交后失效
+func registerCache(db *gorm.DB, qc *queryCache) error {
+	pool := &cachePool{ConnPool: db.ConnPool, cache: qc}
+	db.ConnPool = pool
+	db.Statement.ConnPool = pool
+
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("grds:cache_invalidate", qc.invalidateWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("grds:cache_invalidate", qc.invalidateWrite); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("grds:cache_invalidate", qc.invalidateWrite)
+}
+
+// cachePool 包装连接池，事务提交后再次失效事务中写入过的表
+//
+// 在连接池上开启事务能覆盖 Client.Transaction、Begin/Commit、TxManager 等所有方式，
+// 包括直接调用 gorm 的 tx.Commit()。
+type cachePool struct {
+	gorm.ConnPool
+	cache *queryCache
+}
+
+// BeginTx 开启事务并记录事务中写入的表
+func (p *cachePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		conn gorm.ConnPool
+		err  error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		var tx *sql.Tx
+		if tx, err = beginner.BeginTx(ctx, opts); err == nil {
+			conn = tx
+		}
+	case gorm.ConnPoolBeginner:
+		conn, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch tx := conn.(type) {
+	case *gorm.PreparedStmtTX:
+		// 保持 *gorm.PreparedStmtTX 在最外层，SavePoint 依赖它绕过预编译语句
+		tx.Tx = &cacheTx{Tx: tx.Tx, pool: p}
+		return tx, nil
+	case gorm.Tx:
+		return &cacheTx{Tx: tx, pool: p}, nil
+	default:
+		return conn, nil
+	}
+}
+
+// GetDBConn 返回底层的 *sql.DB，供 gorm.DB.DB() 使用
+func (p *cachePool) GetDBConn() (*sql.DB, error) {
+	switch pool := p.ConnPool.(type) {
+	case *sql.DB:
+		return pool, nil
+	case gorm.GetDBConnector:
+		return pool.GetDBConn()
+	default:
+		return nil, gorm.ErrInvalidDB
+	}
+}
+
+// cacheTx 记录写入过的表，提交成功后失效
+type cacheTx struct {
+	gorm.Tx
+	pool *cachePool
+
+	mu   sync.Mutex
+	tags []string
+}
+
+// unwrapCacheTx 取出语句所在的 cacheTx，不在事务中时返回 nil
+func unwrapCacheTx(conn gorm.ConnPool) *cacheTx {
+	switch tx := conn.(type) {
+	case *cacheTx:
+		return tx
+	case *gorm.PreparedStmtTX:
+		return unwrapCacheTx(tx.Tx)
+	default:
+		return nil
+	}
+}
+
+// add 记录标签
+func (t *cacheTx) add(tag string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, existing := range t.tags {
+		if existing == tag {
+			return
+		}
+	}
+	t.tags = append(t.tags, tag)
+}
+
+// Commit 提交事务，成功后失效事务中写入过的表
+func (t *cacheTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	tags := t.tags
+	t.tags = nil
+	t.mu.Unlock()
+	if len(tags) > 0 {
+		t.pool.cache.invalidate(context.Background(), tags...)
+	}
+	return nil
+}
+
+// GetDBConn 返回底层的 *sql.DB，供 gorm.DB.DB() 使用
+func (t *cacheTx) GetDBConn() (*sql.DB, error) {
+	return t.pool.GetDBConn()
+}
+
+// ==================== LRU 实现 ====================
+
+// LRUCache 进程内 LRU 缓存，支持过期时间和标签失效
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{}
+}
+
+// lruEntry LRU 缓存条目
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// NewLRUCache 创建 LRU 缓存，capacity <= 0 时使用 DefaultCacheCapacity
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheCapacity
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+// Get 读取缓存
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set 写入缓存，超出容量时淘汰最久未使用的条目
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+
+	entry := &lruEntry{key: key, value: value, tags: tags}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for c.ll.Len() > c.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Invalidate 删除带有任一标签的条目
+func (c *LRUCache) Invalidate(ctx context.Context, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if elem, ok := c.items[key]; ok {
+				c.remove(elem)
+			}
+		}
+	}
+}
+
+// Len 当前条目数
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Purge 清空缓存
+func (c *LRUCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// remove 删除条目及其标签索引
+func (c *LRUCache) remove(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*lruEntry)
+	delete(c.items, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
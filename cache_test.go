@@ -0,0 +1,341 @@
+package grds
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	type step struct {
+		op    string // set、get、invalidate
+		key   string
+		ttl   time.Duration
+		tags  []string
+		found bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		len   int
+	}{
+		{"hit and miss", []step{
+			{op: "set", key: "a"},
+			{op: "get", key: "a", found: true},
+			{op: "get", key: "b"},
+		}, 1},
+		{"evict least recently used", []step{
+			{op: "set", key: "a"},
+			{op: "set", key: "b"},
+			{op: "get", key: "a", found: true},
+			{op: "set", key: "c"},
+			{op: "get", key: "b"},
+			{op: "get", key: "a", found: true},
+			{op: "get", key: "c", found: true},
+		}, 2},
+		{"overwrite keeps one entry", []step{
+			{op: "set", key: "a", tags: []string{"users"}},
+			{op: "set", key: "a", tags: []string{"orders"}},
+			{op: "invalidate", tags: []string{"users"}},
+			{op: "get", key: "a", found: true},
+		}, 1},
+		{"expired", []step{
+			{op: "set", key: "a", ttl: time.Nanosecond},
+			{op: "sleep"},
+			{op: "get", key: "a"},
+		}, 0},
+		{"invalidate by tag", []step{
+			{op: "set", key: "a", tags: []string{"users"}},
+			{op: "set", key: "b", tags: []string{"users", "orders"}},
+			{op: "invalidate", tags: []string{"orders"}},
+			{op: "get", key: "a", found: true},
+			{op: "get", key: "b"},
+			{op: "invalidate", tags: []string{"users", "missing"}},
+			{op: "get", key: "a"},
+		}, 0},
+	}
+	for _, tt := range tests {
+		c := NewLRUCache(2)
+		for i, s := range tt.steps {
+			switch s.op {
+			case "set":
+				c.Set(ctx, s.key, []byte(s.key), s.ttl, s.tags)
+			case "get":
+				value, ok := c.Get(ctx, s.key)
+				if ok != s.found || (ok && string(value) != s.key) {
+					t.Errorf("%s: step %d Get(%q) = %q, %v, want found %v", tt.name, i, s.key, value, ok, s.found)
+				}
+			case "invalidate":
+				c.Invalidate(ctx, s.tags...)
+			case "sleep":
+				time.Sleep(time.Millisecond)
+			}
+		}
+		if got := c.Len(); got != tt.len {
+			t.Errorf("%s: Len() = %d, want %d", tt.name, got, tt.len)
+		}
+	}
+}
+
+func TestLRUCachePurge(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(0)
+	if c.capacity != DefaultCacheCapacity {
+		t.Errorf("capacity = %d, want %d", c.capacity, DefaultCacheCapacity)
+	}
+	c.Set(ctx, "a", []byte("1"), 0, []string{"users"})
+	c.Purge()
+	if _, ok := c.Get(ctx, "a"); ok || c.Len() != 0 || len(c.tags) != 0 {
+		t.Errorf("purge left %d entries, %d tags", c.Len(), len(c.tags))
+	}
+}
+
+// nullableUser 含可空字段的模型，指向零值的指针与 nil 必须区分
+type nullableUser struct {
+	ID    int64
+	Score *int
+	Name  *string
+	Note  *string
+}
+
+func (nullableUser) TableName() string { return "users" }
+
+func TestCacheKeepsZeroPointers(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+	mock.ExpectQuery("SELECT * FROM `users` WHERE `id` = ?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "score", "name", "note"}).AddRow(1, 0, "", nil))
+
+	// 第二次读取命中缓存，sqlmock 只允许一次查询
+	var results [2][]nullableUser
+	for i := range results {
+		if err := c.Model(&nullableUser{}).WhereEq("id", 1).Cache(time.Minute).Find(&results[i]); err != nil {
+			t.Fatalf("find %d: %v", i, err)
+		}
+	}
+	for i, users := range results {
+		if len(users) != 1 {
+			t.Fatalf("find %d: got %d rows, want 1", i, len(users))
+		}
+		u := users[0]
+		if u.ID != 1 || u.Score == nil || *u.Score != 0 || u.Name == nil || *u.Name != "" || u.Note != nil {
+			t.Errorf("find %d: got %+v, want score 0, name \"\", note nil", i, u)
+		}
+	}
+	if results[0][0].Score == results[1][0].Score {
+		t.Error("cached result shares pointers with the original")
+	}
+}
+
+func TestCacheMaps(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+	mock.ExpectQuery("SELECT * FROM `config`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow("site", []byte("")).AddRow("mode", nil))
+
+	var results [2][]map[string]interface{}
+	for i := range results {
+		if err := c.Table("config").Cache(time.Minute).Find(&results[i]); err != nil {
+			t.Fatalf("find %d: %v", i, err)
+		}
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("cached maps = %#v, want %#v", results[1], results[0])
+	}
+}
+
+func TestResultSetEncoding(t *testing.T) {
+	set := &resultSet{
+		columns: []resultColumn{
+			{name: "id", databaseType: "BIGINT", scanType: reflect.TypeOf(int64(0)), nullableOK: true},
+			{name: "score", databaseType: "INT", scanType: reflect.TypeOf(sql.NullInt64{}), nullable: true, nullableOK: true},
+			{name: "raw"},
+		},
+		rows: [][]driver.Value{
+			{int64(-1), int64(0), []byte{}},
+			{uint64(1 << 63), nil, "text"},
+			{float64(0.5), true, time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)},
+		},
+	}
+	data, err := encodeResultSet(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeResultSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, set) {
+		t.Errorf("decoded %#v, want %#v", got, set)
+	}
+
+	// 外部缓存中的损坏数据不能导致 panic 或超大分配
+	for i := range data {
+		if _, err := decodeResultSet(data[:i]); err == nil {
+			t.Errorf("decode of %d/%d bytes succeeded", i, len(data))
+		}
+	}
+	if _, err := decodeResultSet([]byte{resultSetVersion, 0, 0xff, 0xff, 0xff, 0xff, 0x0f}); err == nil {
+		t.Error("decode accepted an oversized row count")
+	}
+
+	if _, err := encodeResultSet(&resultSet{rows: [][]driver.Value{{struct{}{}}}}); err == nil {
+		t.Error("encode accepted an unsupported value")
+	}
+}
+
+func TestStatementTable(t *testing.T) {
+	tests := []struct {
+		table string
+		expr  string // 空表示没有 TableExpr
+		want  string
+	}{
+		{"users", "", "users"},
+		{"users", "`users`", "users"},
+		{"u", "`users` `u`", "users"},
+		{"u", "`users` AS `u`", "users"},
+		{"users", "`app`.`users`", "app.users"},
+		{"t", "(SELECT * FROM `users`) AS `t`", ""},
+		{"o", "users u, orders o", ""},
+	}
+	for _, tt := range tests {
+		stmt := &gorm.Statement{Table: tt.table}
+		if tt.expr != "" {
+			stmt.TableExpr = &clause.Expr{SQL: tt.expr}
+		}
+		if got := statementTable(stmt); got != tt.want {
+			t.Errorf("statementTable(%q, %q) = %q, want %q", tt.table, tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCacheTableAliasInvalidation(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+	query := "SELECT * FROM users u"
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE `users` SET `score`=? WHERE `id` = ?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	find := func() {
+		t.Helper()
+		var rows []map[string]interface{}
+		if err := c.Table("users u").Cache(time.Minute).Find(&rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+	find()
+	find() // 命中缓存
+	if err := c.Model(&nullableUser{}).WhereEq("id", 1).Update("score", 1); err != nil {
+		t.Fatal(err)
+	}
+	find() // 写入 users 后失效
+}
+
+func TestCacheRawRequiresTags(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+	query := "SELECT id FROM users WHERE score > ?"
+	// 没有标签：每次都查询数据库
+	mock.ExpectQuery(query).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(query).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// 显式标签：第二次命中缓存
+	mock.ExpectQuery(query).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	for _, tags := range [][]string{nil, nil, {CacheTableTag("users")}, {CacheTableTag("users")}} {
+		var users []nullableUser
+		if err := c.Model(&nullableUser{}).Raw(query, 0).Cache(time.Minute, tags...).Find(&users); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCacheInvalidatesAfterCommit(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(c *Client, fc TxFunc) error
+	}{
+		{"Client.Transaction", func(c *Client, fc TxFunc) error {
+			return c.Transaction(fc)
+		}},
+		{"Client.Begin", func(c *Client, fc TxFunc) error {
+			tx := c.Begin()
+			if err := fc(tx); err != nil {
+				tx.Rollback()
+				return err
+			}
+			return tx.Commit().Error
+		}},
+		{"TxManager", func(c *Client, fc TxFunc) error {
+			return NewTxManager(c.DB()).Execute(fc)
+		}},
+		{"Transaction", func(c *Client, fc TxFunc) error {
+			return Transaction(c.DB(), fc)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+			query := "SELECT * FROM `users`"
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE `users` SET `score`=? WHERE id = ?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			// 提交前的并发读取得到旧数据并写入缓存
+			mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "score"}).AddRow(1, 0))
+			mock.ExpectCommit()
+			mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "score"}).AddRow(1, 1))
+
+			find := func() []nullableUser {
+				t.Helper()
+				var users []nullableUser
+				if err := c.Model(&nullableUser{}).Cache(time.Minute).Find(&users); err != nil {
+					t.Fatal(err)
+				}
+				return users
+			}
+			err := tt.run(c, func(tx *gorm.DB) error {
+				if err := tx.Model(&nullableUser{}).Where("id = ?", 1).Update("score", 1).Error; err != nil {
+					return err
+				}
+				find()
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if users := find(); len(users) != 1 || *users[0].Score != 1 {
+				t.Errorf("after commit got %+v, want score 1", users)
+			}
+		})
+	}
+}
+
+func TestCachePoolWrapping(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithCache(NewLRUCache(0)))
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	if _, err := c.SqlDB(); err != nil {
+		t.Errorf("SqlDB() = %v", err)
+	}
+	tx := c.DB().Session(&gorm.Session{PrepareStmt: true}).Begin()
+	if err := tx.Error; err != nil {
+		t.Fatal(err)
+	}
+	// SavePoint 依赖最外层的 *gorm.PreparedStmtTX
+	if _, ok := tx.Statement.ConnPool.(*gorm.PreparedStmtTX); !ok {
+		t.Errorf("ConnPool = %T, want *gorm.PreparedStmtTX", tx.Statement.ConnPool)
+	}
+	if unwrapCacheTx(tx.Statement.ConnPool) == nil || !inTransaction(tx) {
+		t.Error("prepared transaction does not record written tables")
+	}
+	if _, err := tx.DB(); err != nil {
+		t.Errorf("tx.DB() = %v", err)
+	}
+	if err := tx.Rollback().Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 type Client struct {
 	db     *gorm.DB
 	config *Config
+	cache  *queryCache
 	mu     sync.RWMutex
 	closed bool
 }
@@ -58,11 +59,55 @@ func NewClient(config *Config) (*Client, error) {
 	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
+	return NewClientFromDB(db, config)
+}
+
+// NewClientFromDB 使用已打开的 GORM 连接创建客户端
+//
+// 连接池等设置由调用方负责，这里只注册 grds 的回调和 config 中的插件。
+func NewClientFromDB(db *gorm.DB, config *Config) (*Client, error) {
+	if config == nil {
+		config = NewDefaultConfig()
+	}
+
 	client := &Client{
 		db:     db,
 		config: config,
 	}
 
+	// 注册软删除回调
+	if err := registerSoftDelete(db); err != nil {
+		return nil, fmt.Errorf("failed to register soft delete callbacks: %w", err)
+	}
+
+	// 注册乐观锁回调
+	if err := registerVersion(db); err != nil {
+		return nil, fmt.Errorf("failed to register version callbacks: %w", err)
+	}
+
+	// 注册错误归类回调
+	if err := registerErrorTranslation(db); err != nil {
+		return nil, fmt.Errorf("failed to register error callbacks: %w", err)
+	}
+
+	// 注册 SQL 注释回调
+	if err := registerQueryComment(db, &queryCommenter{defaults: config.QueryTags}); err != nil {
+		return nil, fmt.Errorf("failed to register comment callbacks: %w", err)
+	}
+
+	// 注册查询缓存和查询去重回调
+	executor := &queryExecutor{dedupe: config.DedupeReads}
+	if config.Cache != nil {
+		client.cache = &queryCache{cache: config.Cache}
+		executor.cache = client.cache
+		if err := registerCache(db, client.cache); err != nil {
+			return nil, fmt.Errorf("failed to register cache callbacks: %w", err)
+		}
+	}
+	if err := registerQueryExecutor(db, executor); err != nil {
+		return nil, fmt.Errorf("failed to register query callbacks: %w", err)
+	}
+
 	// 注册插件
 	for _, plugin := range config.Plugins {
 		if err := db.Use(plugin); err != nil {
@@ -153,10 +198,12 @@ func (c *Client) Table(name string, args ...interface{}) *QueryBuilder {
 
 // Model 使用模型进行查询
 func (c *Client) Model(value interface{}) *QueryBuilder {
-	return &QueryBuilder{
+	qb := &QueryBuilder{
 		client: c,
 		db:     c.db.Model(value),
 	}
+	qb.useModelAllowlist(value)
+	return qb
 }
 
 // Transaction 开始事务
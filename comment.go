@@ -0,0 +1,204 @@
+package grds
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	queryTagsKey     = "grds:query_tags"    // QueryBuilder.Comment 设置的标签
+	queryCommentKey  = "grds:query_comment" // 已加到语句前的注释，生成缓存键时去掉
+	commentClause    = "grds:comment"       // 注释子句名，放在 BuildClauses 的最前面
+	queryTagsMaxSize = 1024                 // 注释的最大长度，超过时丢弃多余的标签
+)
+
+// queryTagsContextKey 上下文中的查询标签
+type queryTagsContextKey struct{}
+
+// WithQueryTags 返回带有查询标签的上下文，使用该上下文执行的语句都会带上这些标签
+//
+// 与上下文中已有的标签合并，同名时覆盖。通常在请求入口设置：
+//
+//	ctx = grds.WithQueryTags(ctx, map[string]string{"route": r.URL.Path, "trace_id": traceID})
+func WithQueryTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	if existing, ok := ctx.Value(queryTagsContextKey{}).(map[string]string); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, queryTagsContextKey{}, merged)
+}
+
+// QueryTagsFromContext 返回上下文中的查询标签
+func QueryTagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(queryTagsContextKey{}).(map[string]string)
+	return tags
+}
+
+// Comment 给当前查询添加标签，参数为键值对，优先于上下文和配置中的同名标签
+//
+//	grds.Table("orders").Comment("action", "export", "owner", "billing").Find(&orders)
+func (qb *QueryBuilder) Comment(kv ...string) *QueryBuilder {
+	if len(kv)%2 != 0 {
+		_ = qb.db.AddError(fmt.Errorf("comment: odd number of arguments: %d", len(kv)))
+		return qb
+	}
+	tags := make(map[string]string, len(kv)/2)
+	if existing, ok := qb.db.Get(queryTagsKey); ok {
+		for k, v := range existing.(map[string]string) {
+			tags[k] = v
+		}
+	}
+	for i := 0; i < len(kv); i += 2 {
+		tags[kv[i]] = kv[i+1]
+	}
+	qb.db = qb.db.Set(queryTagsKey, tags)
+	return qb
+}
+
+// FormatQueryTags 按 sqlcommenter 规范生成注释：键排序，键和值 URL 编码，值用单引号包围
+//
+//	FormatQueryTags(map[string]string{"route": "/api/x", "service": "orders"})
+//	// /* route='%2Fapi%2Fx',service='orders' */
+func FormatQueryTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("/* ")
+	for i, k := range keys {
+		pair := sqlcommenterEscape(k) + "='" + sqlcommenterEscape(tags[k]) + "'"
+		if b.Len()+len(pair)+4 > queryTagsMaxSize {
+			break
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pair)
+	}
+	b.WriteString(" */")
+	return b.String()
+}
+
+// sqlcommenterEscape URL 编码后转义单引号；编码后不会出现 */ 和换行
+func sqlcommenterEscape(s string) string {
+	s = strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	return strings.ReplaceAll(s, "'", `\'`)
+}
+
+// queryComment 注释子句
+type queryComment string
+
+// Build 输出注释
+func (c queryComment) Build(builder clause.Builder) {
+	builder.WriteString(string(c))
+}
+
+// queryCommenter 在语句前加上标签注释
+type queryCommenter struct {
+	defaults map[string]string // Config.QueryTags
+}
+
+// annotate 合并配置、上下文和查询链上的标签，把注释加到语句前
+//
+// 由查询构建器生成的语句在 BuildClauses 最前面加入注释子句；Raw/Exec 等已有 SQL 的语句直接在前面拼接。
+func (qc *queryCommenter) annotate(db *gorm.DB) {
+	if db.Error != nil || isSubqueryBuild(db) {
+		return
+	}
+	stmt := db.Statement
+	tags := qc.tags(db)
+	if len(tags) == 0 {
+		return
+	}
+	comment := FormatQueryTags(tags)
+	stmt.Settings.Store(queryCommentKey, comment+" ")
+
+	if stmt.SQL.Len() > 0 {
+		if sql := stmt.SQL.String(); !strings.HasPrefix(sql, comment) {
+			stmt.SQL.Reset()
+			stmt.SQL.WriteString(comment + " " + sql)
+		}
+		return
+	}
+	if len(stmt.BuildClauses) == 0 || stmt.BuildClauses[0] != commentClause {
+		stmt.BuildClauses = append([]string{commentClause}, stmt.BuildClauses...)
+	}
+	stmt.Clauses[commentClause] = clause.Clause{Expression: queryComment(comment)}
+}
+
+// isSubqueryBuild 是否为 gorm 生成子查询（包括 Union 的各部分和 CTE）时的演练
+//
+// gorm 以 DryRun 和 logger.Discard 的会话生成子查询，注释只加在最外层语句上，
+// 否则子查询中按请求变化的标签会使缓存和去重的键各不相同。
+func isSubqueryBuild(db *gorm.DB) bool {
+	return db.DryRun && db.Logger == logger.Discard
+}
+
+// tags 按配置、上下文、查询链的顺序合并标签
+func (qc *queryCommenter) tags(db *gorm.DB) map[string]string {
+	ctxTags := QueryTagsFromContext(db.Statement.Context)
+	qbTags, _ := db.Get(queryTagsKey)
+	if len(ctxTags) == 0 && qbTags == nil {
+		return qc.defaults
+	}
+	tags := make(map[string]string, len(qc.defaults)+len(ctxTags))
+	for k, v := range qc.defaults {
+		tags[k] = v
+	}
+	for k, v := range ctxTags {
+		tags[k] = v
+	}
+	if qbTags != nil {
+		for k, v := range qbTags.(map[string]string) {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// stripQueryComment 去掉语句前的注释，用于生成缓存和去重的键
+func stripQueryComment(db *gorm.DB, sql string) string {
+	if comment, ok := db.Statement.Settings.Load(queryCommentKey); ok {
+		return strings.TrimPrefix(sql, comment.(string))
+	}
+	return sql
+}
+
+// registerQueryComment 注册注释回调
+func registerQueryComment(db *gorm.DB, qc *queryCommenter) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("grds:comment", qc.annotate); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("grds:comment", qc.annotate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("grds:comment", qc.annotate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("grds:comment", qc.annotate); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("grds:comment", qc.annotate); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register("grds:comment", qc.annotate)
+}
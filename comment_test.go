@@ -0,0 +1,106 @@
+package grds
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFormatQueryTags(t *testing.T) {
+	tests := []struct {
+		tags map[string]string
+		want string
+	}{
+		{nil, ""},
+		{map[string]string{"service": "orders", "route": "/api/x"}, "/* route='%2Fapi%2Fx',service='orders' */"},
+		{map[string]string{"trace_id": "a'b */ c"}, "/* trace_id='a%27b%20%2A%2F%20c' */"},
+		{map[string]string{"k y": "line\nbreak"}, "/* k%20y='line%0Abreak' */"},
+	}
+	for _, tt := range tests {
+		if got := FormatQueryTags(tt.tags); got != tt.want {
+			t.Errorf("FormatQueryTags(%v) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+
+	long := map[string]string{"a": strings.Repeat("x", 600), "b": strings.Repeat("y", 600)}
+	if got := FormatQueryTags(long); len(got) > queryTagsMaxSize || strings.Contains(got, "b=") {
+		t.Errorf("oversized comment not truncated: %d bytes", len(got))
+	}
+}
+
+func TestWithQueryTagsMerge(t *testing.T) {
+	ctx := WithQueryTags(context.Background(), map[string]string{"a": "1", "b": "1"})
+	ctx = WithQueryTags(ctx, map[string]string{"b": "2"})
+	got := QueryTagsFromContext(ctx)
+	if got["a"] != "1" || got["b"] != "2" || len(got) != 2 {
+		t.Errorf("merged tags = %v", got)
+	}
+}
+
+// newCommentClient 创建带默认标签 service=svc 的测试客户端
+func newCommentClient(t *testing.T) *testClient {
+	t.Helper()
+	config := NewDefaultConfig()
+	config.QueryTags = map[string]string{"service": "svc"}
+	return newTestClientWithConfig(t, config)
+}
+
+func TestQueryCommentSQL(t *testing.T) {
+	c := newCommentClient(t)
+	ctx := WithQueryTags(context.Background(), map[string]string{"trace_id": "abc"})
+	withCtx := func(qb *QueryBuilder) *QueryBuilder {
+		qb.db = qb.db.WithContext(ctx)
+		return qb
+	}
+
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		op   SQLOp
+		want string
+	}{
+		{
+			name: "find",
+			qb:   withCtx(c.Table("users").Comment("action", "list").WhereEq("id", 1)),
+			op:   SQLFind,
+			want: "/* action='list',service='svc',trace_id='abc' */ SELECT * FROM `users` WHERE `id` = ?",
+		},
+		{
+			name: "subquery",
+			qb:   withCtx(c.Table("orders").WhereInSub("user_id", c.Table("users").Select("id"))),
+			op:   SQLFind,
+			want: "/* service='svc',trace_id='abc' */ SELECT * FROM `orders` WHERE `user_id` IN (SELECT id FROM `users`)",
+		},
+		{
+			name: "union",
+			qb:   withCtx(c.Table("a").Select("id").Union(c.Table("b").Select("id"))),
+			op:   SQLFind,
+			want: "/* service='svc',trace_id='abc' */ SELECT * FROM ((SELECT id FROM `a`) UNION (SELECT id FROM `b`)) AS `" + UnionAlias + "`",
+		},
+		{
+			name: "delete",
+			qb:   c.Table("users").WhereEq("id", 1),
+			op:   SQLDelete,
+			want: "/* service='svc' */ DELETE FROM `users` WHERE `id` = ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := toSQL(t, tt.qb, tt.op); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripQueryComment(t *testing.T) {
+	c := newCommentClient(t)
+	db := c.DB().WithContext(WithQueryTags(context.Background(), map[string]string{"trace_id": "abc"})).
+		Table("orders").Where("user_id IN (?)", c.DB().Table("users").Select("id")).Find(&[]map[string]interface{}{})
+	if db.Error != nil {
+		t.Fatal(db.Error)
+	}
+	if got := stripQueryComment(db, db.Statement.SQL.String()); strings.Contains(got, "/*") {
+		t.Errorf("comment left in cache key: %q", got)
+	}
+}
@@ -0,0 +1,351 @@
+package grds
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnionAlias Union/UnionAll 结果作为派生表时的别名
+const UnionAlias = "union_result"
+
+// TreeDepthColumn 树查询结果中的层级列，起始节点为 0
+const TreeDepthColumn = "depth"
+
+// ==================== UNION ====================
+
+// Union 合并查询结果并去重，返回以合并结果为数据源的新查询构建器
+//
+// 生成 SELECT * FROM ((q1) UNION (q2)) AS union_result，可继续追加条件、排序和分页。
+func (qb *QueryBuilder) Union(others ...*QueryBuilder) *QueryBuilder {
+	return qb.union("UNION", others)
+}
+
+// UnionAll 合并查询结果（保留重复行）
+func (qb *QueryBuilder) UnionAll(others ...*QueryBuilder) *QueryBuilder {
+	return qb.union("UNION ALL", others)
+}
+
+// union 生成合并查询
+func (qb *QueryBuilder) union(op string, others []*QueryBuilder) *QueryBuilder {
+	result := &QueryBuilder{
+		client: qb.client,
+		db:     qb.db.Session(&gorm.Session{NewDB: true}),
+	}
+
+	parts := make([]string, 0, len(others)+1)
+	vars := make([]interface{}, 0, len(others)+1)
+	for _, part := range append([]*QueryBuilder{qb}, others...) {
+		db, ok := result.subDB(part)
+		if !ok {
+			return result
+		}
+		parts = append(parts, "(?)")
+		vars = append(vars, db)
+	}
+
+	result.db = result.db.Table("("+strings.Join(parts, " "+op+" ")+") AS `"+UnionAlias+"`", vars...)
+	return result
+}
+
+// ==================== CTE ====================
+
+// cte 一个公用表表达式
+type cte struct {
+	name      string
+	columns   []string
+	recursive bool
+	parts     []*gorm.DB // 递归 CTE 为锚点查询和递归查询，以 UNION ALL 连接
+}
+
+// withClause WITH 子句，作为 SELECT 子句的前置表达式输出
+type withClause struct {
+	ctes []cte
+}
+
+// Name 挂载到 SELECT 子句
+func (withClause) Name() string {
+	return "SELECT"
+}
+
+// Build 生成 WITH [RECURSIVE] name [(columns)] AS (...)
+func (w withClause) Build(builder clause.Builder) {
+	builder.WriteString("WITH ")
+	for _, c := range w.ctes {
+		if c.recursive {
+			builder.WriteString("RECURSIVE ")
+			break
+		}
+	}
+
+	for i, c := range w.ctes {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteQuoted(c.name)
+		if len(c.columns) > 0 {
+			builder.WriteString(" (")
+			for j, column := range c.columns {
+				if j > 0 {
+					builder.WriteString(", ")
+				}
+				builder.WriteQuoted(column)
+			}
+			builder.WriteString(")")
+		}
+		builder.WriteString(" AS (")
+		for j, part := range c.parts {
+			if j > 0 {
+				builder.WriteString(" UNION ALL ")
+			}
+			builder.AddVar(builder, part)
+		}
+		builder.WriteString(")")
+	}
+}
+
+// MergeClause 合并多个 With 调用，保留 SELECT 子句的其他部分
+func (w withClause) MergeClause(c *clause.Clause) {
+	if prev, ok := c.BeforeExpression.(withClause); ok {
+		w.ctes = append(append([]cte{}, prev.ctes...), w.ctes...)
+	}
+	c.BeforeExpression = w
+}
+
+// With 添加公用表表达式：WITH name AS (SELECT ...)
+//
+// 主查询通过 Table(name) 引用 CTE，仅对查询语句生效。
+func (qb *QueryBuilder) With(name string, sub *QueryBuilder, columns ...string) *QueryBuilder {
+	return qb.with(cte{name: name, columns: columns}, sub)
+}
+
+// WithRecursive 添加递归公用表表达式：WITH RECURSIVE name AS (anchor UNION ALL recursive)
+//
+// recursive 查询中通过 name 引用自身。
+func (qb *QueryBuilder) WithRecursive(name string, anchor, recursive *QueryBuilder, columns ...string) *QueryBuilder {
+	return qb.with(cte{name: name, columns: columns, recursive: true}, anchor, recursive)
+}
+
+// with 校验并添加 CTE
+func (qb *QueryBuilder) with(c cte, subs ...*QueryBuilder) *QueryBuilder {
+	for _, name := range append([]string{c.name}, c.columns...) {
+		if !ValidIdentifier(name) || strings.Contains(name, ".") {
+			_ = qb.db.AddError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+			return qb
+		}
+	}
+	for _, sub := range subs {
+		db, ok := qb.subDB(sub)
+		if !ok {
+			return qb
+		}
+		c.parts = append(c.parts, db)
+	}
+
+	qb.db = qb.db.Clauses(withClause{ctes: []cte{c}})
+	return qb
+}
+
+// TreeDescendants 查询树形表中某节点及其全部子孙节点（递归 CTE）
+//
+// 结果额外包含 depth 列表示相对起始节点的层级：
+//
+//	client.TreeDescendants("categories", "id", "parent_id", 1).OrderByAsc("depth").Find(&cats)
+func (c *Client) TreeDescendants(table, idColumn, parentColumn string, rootID interface{}) *QueryBuilder {
+	return c.tree(table, idColumn, parentColumn, rootID, false)
+}
+
+// TreeAncestors 查询树形表中某节点及其全部祖先节点（递归 CTE），depth 为向上的层级
+func (c *Client) TreeAncestors(table, idColumn, parentColumn string, id interface{}) *QueryBuilder {
+	return c.tree(table, idColumn, parentColumn, id, true)
+}
+
+// tree 生成树查询
+func (c *Client) tree(table, idColumn, parentColumn string, start interface{}, up bool) *QueryBuilder {
+	const name = "grds_tree"
+	qb := c.Table(name)
+
+	quoted := make([]string, 0, 3)
+	for _, ident := range []string{table, idColumn, parentColumn} {
+		q, err := QuoteIdentifier(ident)
+		if err != nil || (ident != table && strings.Contains(ident, ".")) {
+			_ = qb.db.AddError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, ident))
+			return qb
+		}
+		quoted = append(quoted, q)
+	}
+	tbl, id, parent := quoted[0], quoted[1], quoted[2]
+
+	// 向下：子节点的 parent = 已找到节点的 id；向上：子节点的 id = 已找到节点的 parent
+	on := "`t`." + parent + " = `" + name + "`." + id
+	if up {
+		on = "`t`." + id + " = `" + name + "`." + parent
+	}
+
+	anchor := c.Table(tbl+" AS `t`").
+		Select("`t`.*, 0 AS `"+TreeDepthColumn+"`").
+		Where("`t`."+id+" = ?", start)
+	recursive := c.Table(tbl + " AS `t`").
+		Select("`t`.*, `" + name + "`.`" + TreeDepthColumn + "` + 1").
+		Joins("JOIN `" + name + "` ON " + on)
+
+	return qb.WithRecursive(name, anchor, recursive)
+}
+
+// ==================== 窗口函数 ====================
+
+// WindowFunc 窗口函数表达式，如 ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...) AS rn
+type WindowFunc struct {
+	fn        string
+	args      []string
+	partition []string
+	order     []string
+	alias     string
+	err       error
+}
+
+// Window 自定义窗口函数，fn 为函数名（如 "NTILE"），args 为原样输出的参数（如 "4"）
+//
+// fn 和 args 不会被转义，不能包含外部输入。
+func Window(fn string, args ...string) *WindowFunc {
+	return &WindowFunc{fn: fn, args: args}
+}
+
+// RowNumber ROW_NUMBER()
+func RowNumber() *WindowFunc {
+	return Window("ROW_NUMBER")
+}
+
+// Rank RANK()
+func Rank() *WindowFunc {
+	return Window("RANK")
+}
+
+// DenseRank DENSE_RANK()
+func DenseRank() *WindowFunc {
+	return Window("DENSE_RANK")
+}
+
+// Lag LAG(column, offset)
+func Lag(column string, offset int) *WindowFunc {
+	return windowOf("LAG", column, offset)
+}
+
+// Lead LEAD(column, offset)
+func Lead(column string, offset int) *WindowFunc {
+	return windowOf("LEAD", column, offset)
+}
+
+// SumOver SUM(column) OVER (...)，用于累计求和
+func SumOver(column string) *WindowFunc {
+	return windowOf("SUM", column, 0)
+}
+
+// CountOver COUNT(*) OVER (...)
+func CountOver() *WindowFunc {
+	return Window("COUNT", "*")
+}
+
+// windowOf 生成以列为参数的窗口函数
+func windowOf(fn, column string, offset int) *WindowFunc {
+	w := Window(fn)
+	col, err := QuoteIdentifier(column)
+	if err != nil {
+		w.err = err
+		return w
+	}
+	w.args = append(w.args, col)
+	if offset > 0 {
+		w.args = append(w.args, fmt.Sprint(offset))
+	}
+	return w
+}
+
+// PartitionBy PARTITION BY 列
+func (w *WindowFunc) PartitionBy(columns ...string) *WindowFunc {
+	for _, column := range columns {
+		col, err := QuoteIdentifier(column)
+		if err != nil {
+			w.err = err
+			return w
+		}
+		w.partition = append(w.partition, col)
+	}
+	return w
+}
+
+// OrderBy 窗口内排序，列可带 ASC/DESC 后缀，如 "created_at DESC"
+func (w *WindowFunc) OrderBy(columns ...string) *WindowFunc {
+	for _, column := range columns {
+		fields := strings.Fields(column)
+		direction := ""
+		if len(fields) == 2 && (strings.EqualFold(fields[1], "ASC") || strings.EqualFold(fields[1], "DESC")) {
+			direction = " " + strings.ToUpper(fields[1])
+			column = fields[0]
+		}
+		col, err := QuoteIdentifier(column)
+		if err != nil {
+			w.err = err
+			return w
+		}
+		w.order = append(w.order, col+direction)
+	}
+	return w
+}
+
+// As 结果别名
+func (w *WindowFunc) As(alias string) *WindowFunc {
+	if !ValidIdentifier(alias) || strings.Contains(alias, ".") {
+		w.err = fmt.Errorf("%w: %q", ErrInvalidIdentifier, alias)
+		return w
+	}
+	w.alias = alias
+	return w
+}
+
+// Build 实现 clause.Expression
+func (w *WindowFunc) Build(builder clause.Builder) {
+	if w.err != nil {
+		_ = builder.AddError(w.err)
+		return
+	}
+
+	builder.WriteString(w.fn + "(" + strings.Join(w.args, ", ") + ") OVER (")
+	if len(w.partition) > 0 {
+		builder.WriteString("PARTITION BY " + strings.Join(w.partition, ", "))
+	}
+	if len(w.order) > 0 {
+		if len(w.partition) > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("ORDER BY " + strings.Join(w.order, ", "))
+	}
+	builder.WriteString(")")
+	if w.alias != "" {
+		builder.WriteString(" AS `" + w.alias + "`")
+	}
+}
+
+// SelectWindow 选择字段并追加窗口函数列
+//
+//	// 每个用户最近的 3 个订单
+//	ranked := grds.Table("orders").SelectWindow("*",
+//	    grds.RowNumber().PartitionBy("user_id").OrderBy("created_at DESC").As("rn"))
+//	grds.Table("orders").FromSub(ranked, "t").WhereLte("t.rn", 3).Find(&orders)
+func (qb *QueryBuilder) SelectWindow(columns string, fns ...*WindowFunc) *QueryBuilder {
+	parts := make([]string, 0, len(fns)+1)
+	if strings.TrimSpace(columns) != "" {
+		parts = append(parts, columns)
+	}
+	vars := make([]interface{}, 0, len(fns))
+	for _, fn := range fns {
+		parts = append(parts, "?")
+		vars = append(vars, fn)
+	}
+	if len(vars) == 0 {
+		return qb.Select(columns)
+	}
+	return qb.Select(strings.Join(parts, ", "), vars...)
+}
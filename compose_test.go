@@ -0,0 +1,88 @@
+package grds
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestComposeSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+		vars []interface{}
+	}{
+		{"union",
+			c.Table("users").Select("id").WhereEq("status", 1).Union(c.Table("admins").Select("id")).OrderByAsc("id").Limit(10),
+			"SELECT * FROM ((SELECT id FROM `users` WHERE `status` = ?) UNION (SELECT id FROM `admins`)) AS `union_result` ORDER BY `id` ASC LIMIT 10",
+			[]interface{}{1}},
+		{"union all",
+			c.Table("a").Select("id").UnionAll(c.Table("b").Select("id"), c.Table("c").Select("id").WhereEq("x", 2)),
+			"SELECT * FROM ((SELECT id FROM `a`) UNION ALL (SELECT id FROM `b`) UNION ALL (SELECT id FROM `c` WHERE `x` = ?)) AS `union_result`",
+			[]interface{}{2}},
+		{"with",
+			c.Table("recent").With("recent", c.Table("orders").WhereGt("id", 100), "id", "user_id").WhereEq("user_id", 7),
+			"WITH `recent` (`id`, `user_id`) AS (SELECT * FROM `orders` WHERE `id` > ?) SELECT * FROM `recent` WHERE `user_id` = ?",
+			[]interface{}{100, 7}},
+		{"multiple with",
+			c.Table("a").With("a", c.Table("x")).With("b", c.Table("y")).Joins("JOIN b ON b.id = a.id"),
+			"WITH `a` AS (SELECT * FROM `x`), `b` AS (SELECT * FROM `y`) SELECT * FROM `a` JOIN b ON b.id = a.id",
+			nil},
+		{"tree descendants",
+			c.TreeDescendants("categories", "id", "parent_id", 1).OrderByAsc("depth"),
+			"WITH RECURSIVE `grds_tree` AS (SELECT `t`.*, 0 AS `depth` FROM `categories` AS `t` WHERE `t`.`id` = ? UNION ALL " +
+				"SELECT `t`.*, `grds_tree`.`depth` + 1 FROM `categories` AS `t` JOIN `grds_tree` ON `t`.`parent_id` = `grds_tree`.`id`) " +
+				"SELECT * FROM `grds_tree` ORDER BY `depth` ASC",
+			[]interface{}{1}},
+		{"tree ancestors",
+			c.TreeAncestors("categories", "id", "parent_id", 9),
+			"WITH RECURSIVE `grds_tree` AS (SELECT `t`.*, 0 AS `depth` FROM `categories` AS `t` WHERE `t`.`id` = ? UNION ALL " +
+				"SELECT `t`.*, `grds_tree`.`depth` + 1 FROM `categories` AS `t` JOIN `grds_tree` ON `t`.`id` = `grds_tree`.`parent_id`) " +
+				"SELECT * FROM `grds_tree`",
+			[]interface{}{9}},
+		{"window",
+			c.Table("orders").SelectWindow("*", RowNumber().PartitionBy("user_id").OrderBy("created_at desc", "id").As("rn"), Lag("amount", 1).OrderBy("id")),
+			"SELECT *, ROW_NUMBER() OVER (PARTITION BY `user_id` ORDER BY `created_at` DESC, `id`) AS `rn`, LAG(`amount`, 1) OVER (ORDER BY `id`) FROM `orders`",
+			nil},
+		{"window from sub",
+			c.Table("orders").FromSub(c.Table("orders").SelectWindow("id", SumOver("amount").OrderBy("id").As("total")), "t").WhereLte("t.total", 100),
+			"SELECT * FROM (SELECT id, SUM(`amount`) OVER (ORDER BY `id`) AS `total` FROM `orders`) AS `t` WHERE `t`.`total` <= ?",
+			[]interface{}{100}},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		sql, vars := c.lastSQL(t, tt.qb.Find(&rows))
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestComposeInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		err  error
+	}{
+		{"union nil", c.Table("a").Union(nil), nil},
+		{"union failed part", c.Table("a").Union(c.Table("b").WhereEq("x;", 1)), ErrInvalidIdentifier},
+		{"with name", c.Table("a").With("a.b", c.Table("x")), ErrInvalidIdentifier},
+		{"with column", c.Table("a").With("a", c.Table("x"), "id id"), ErrInvalidIdentifier},
+		{"tree column", c.TreeDescendants("categories", "t.id", "parent_id", 1), ErrInvalidIdentifier},
+		{"window alias", c.Table("a").SelectWindow("*", RowNumber().As("a.b")), ErrInvalidIdentifier},
+		{"window partition", c.Table("a").SelectWindow("*", Rank().PartitionBy("x;")), ErrInvalidIdentifier},
+	}
+	for _, tt := range tests {
+		err := tt.qb.Error()
+		if err == nil {
+			var rows []map[string]interface{}
+			err = tt.qb.Find(&rows)
+		}
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
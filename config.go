@@ -43,6 +43,13 @@ type Config struct {
 
 	// GORM 插件和回调
 	Plugins []gorm.Plugin `json:"-" yaml:"-"` // 插件列表
+
+	// 查询缓存和去重
+	Cache       Cache `json:"-" yaml:"-"`                       // 查询结果缓存，为 nil 时 QueryBuilder.Cache 不生效
+	DedupeReads bool  `json:"dedupe_reads" yaml:"dedupe_reads"` // 合并相同的并发查询，默认 false
+
+	// SQL 注释
+	QueryTags map[string]string `json:"query_tags" yaml:"query_tags"` // 加到每条语句前的默认标签，如 service、env
 }
 
 // NewDefaultConfig 创建默认配置
@@ -143,6 +150,12 @@ func (c *Config) Clone() *Config {
 		newConfig.Params[k] = v
 	}
 	newConfig.Plugins = append([]gorm.Plugin{}, c.Plugins...)
+	if c.QueryTags != nil {
+		newConfig.QueryTags = make(map[string]string, len(c.QueryTags))
+		for k, v := range c.QueryTags {
+			newConfig.QueryTags[k] = v
+		}
+	}
 	return &newConfig
 }
 
@@ -245,6 +258,27 @@ func (c *Config) WithPlugin(plugin gorm.Plugin) *Config {
 	return c
 }
 
+// WithCache 设置查询结果缓存
+func (c *Config) WithCache(cache Cache) *Config {
+	c.Cache = cache
+	return c
+}
+
+// WithDedupeReads 设置是否合并相同的并发查询
+func (c *Config) WithDedupeReads(enable bool) *Config {
+	c.DedupeReads = enable
+	return c
+}
+
+// WithQueryTag 添加加到每条语句前的默认标签
+func (c *Config) WithQueryTag(key, value string) *Config {
+	if c.QueryTags == nil {
+		c.QueryTags = make(map[string]string)
+	}
+	c.QueryTags[key] = value
+	return c
+}
+
 // LogLevelInfo 设置日志级别为 Info
 func (c *Config) LogLevelInfo() *Config {
 	c.LogLevel = logger.Info
@@ -0,0 +1,79 @@
+package grds
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const dedupeKey = "grds:dedupe"
+
+// Dedupe 合并相同的并发查询（SQL 和参数都相同），只访问一次数据库
+//
+// 调用者共享查询返回的原始值，各自重新扫描到自己的目标，得到互不共享内存的结果。事务中和加锁的查询不合并。Config.DedupeReads 为 true 时所有查询默认合并。
+//
+//	grds.Model(&User{}).Dedupe().First(&user, id)
+func (qb *QueryBuilder) Dedupe() *QueryBuilder {
+	qb.db = qb.db.Set(dedupeKey, true)
+	return qb
+}
+
+// flightGroup 进行中的查询
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall 一次进行中的查询及其结果
+type flightCall struct {
+	done chan struct{}
+	set  *resultSet
+	err  error
+}
+
+// query 第一个调用者执行查询，其他相同的调用者等待并共享原始结果，失败时记录错误并返回 nil
+func (g *flightGroup) query(db *gorm.DB, key string) *resultSet {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-db.Statement.Context.Done():
+			db.AddError(db.Statement.Context.Err())
+			return nil
+		}
+		return call.result(db)
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.set = fetchResultSet(db)
+	call.err = db.Error
+	return call.set
+}
+
+// result 等待者取得共享的结果
+func (c *flightCall) result(db *gorm.DB) *resultSet {
+	if c.err != nil {
+		// 第一个调用者的 context 取消不影响其他调用者
+		if errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded) {
+			return fetchResultSet(db)
+		}
+		db.AddError(c.err)
+		return nil
+	}
+	return c.set
+}
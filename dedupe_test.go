@@ -0,0 +1,67 @@
+package grds
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+func TestDedupeConcurrent(t *testing.T) {
+	c, mock := newMockClient(t, NewDefaultConfig().WithDedupeReads(true))
+	// 延迟返回，让所有调用者在查询进行中到达；多出的查询会因没有对应的期望而失败
+	mock.ExpectQuery("SELECT * FROM `users` WHERE `id` = ?").WithArgs(1).
+		WillDelayFor(200 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "score", "name", "note"}).AddRow(1, 0, "", nil))
+
+	const callers = 8
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		results [callers][]nullableUser
+		errs    [callers]error
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = c.Model(&nullableUser{}).WhereEq("id", 1).Find(&results[i])
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	scores := make(map[*int]bool)
+	for i, users := range results {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: %v", i, errs[i])
+		}
+		if len(users) != 1 {
+			t.Fatalf("caller %d: got %d rows, want 1", i, len(users))
+		}
+		u := users[0]
+		if u.ID != 1 || u.Score == nil || *u.Score != 0 || u.Name == nil || *u.Name != "" || u.Note != nil {
+			t.Errorf("caller %d: got %+v, want score 0, name \"\", note nil", i, u)
+		}
+		scores[u.Score] = true
+	}
+	if len(scores) != callers {
+		t.Errorf("callers share pointer fields: %d distinct of %d", len(scores), callers)
+	}
+}
+
+func TestDedupeNotFound(t *testing.T) {
+	c, mock := newMockClient(t, nil)
+	mock.ExpectQuery("SELECT * FROM `users` WHERE `id` = ? LIMIT 1").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var user nullableUser
+	err := c.Model(&nullableUser{}).WhereEq("id", 2).Dedupe().Take(&user)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Take = %v, want ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,343 @@
+// Package election 基于 MySQL 的 leader 选举，保证同一任务只有一个副本在运行
+//
+// BackendLease（默认）在 grds_leases 表中写入带过期时间的租约并定期续约，过期判断使用数据库时间；
+// leader 在本地计时的租约到期前未能续约会主动卸任，数据库短暂不可用时不会出现两个 leader。
+// BackendLock 以持有 GET_LOCK 命名锁的连接作为 leader，连接断开时锁自动释放；
+// 锁丢失要到下一次检查（grds.AdvisoryLockCheckInterval，1 秒）才能发现，其间可能短暂出现两个 leader。
+//
+//	e, err := election.New(client, election.Options{
+//	    Name: "report-cron",
+//	    OnElected: func(ctx context.Context) {
+//	        runCron(ctx) // ctx 在卸任时取消
+//	    },
+//	    OnDemoted: func() { log.Println("demoted") },
+//	})
+//	go e.Run(ctx)
+package election
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nicexiaonie/grds"
+	"gorm.io/gorm"
+)
+
+// DefaultTable 默认租约表名
+const DefaultTable = "grds_leases"
+
+// Backend 选举后端
+type Backend int
+
+const (
+	BackendLease Backend = iota // 租约表 + 心跳续约
+	BackendLock                 // GET_LOCK 命名锁
+)
+
+// Lease 租约记录
+type Lease struct {
+	Name      string    `gorm:"primaryKey;size:191"`
+	Holder    string    `gorm:"size:191;not null;default:''"`
+	ExpiresAt time.Time `gorm:"type:datetime(6);not null"`
+}
+
+// TableName 默认表名
+func (Lease) TableName() string {
+	return DefaultTable
+}
+
+// Options 选举配置
+type Options struct {
+	Name          string                    // 选举名，同一任务的所有副本使用相同的名字
+	Identity      string                    // 当前副本标识，默认为 主机名-进程号-随机数
+	Backend       Backend                   // 选举后端，默认 BackendLease
+	LeaseTTL      time.Duration             // 租约时长，默认 15 秒
+	RenewInterval time.Duration             // 续约间隔，默认 LeaseTTL / 3
+	RetryInterval time.Duration             // 非 leader 时的竞选间隔，默认与 RenewInterval 相同
+	Table         string                    // 租约表名，默认 grds_leases
+	SkipMigrate   bool                      // 不自动创建租约表
+	OnElected     func(ctx context.Context) // 当选时在新的 goroutine 中调用，ctx 在卸任时取消
+	OnDemoted     func()                    // 卸任时调用
+	OnError       func(err error)           // 竞选、续约失败时的回调，可选
+}
+
+// Elector 选举参与者
+type Elector struct {
+	client *grds.Client
+	opts   Options
+
+	mu      sync.Mutex
+	leader  bool
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// New 创建选举参与者，租约后端默认自动创建租约表
+func New(client *grds.Client, opts Options) (*Elector, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("election: name is required")
+	}
+	if opts.Identity == "" {
+		identity, err := defaultIdentity()
+		if err != nil {
+			return nil, err
+		}
+		opts.Identity = identity
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = 15 * time.Second
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.LeaseTTL / 3
+	}
+	if opts.RenewInterval >= opts.LeaseTTL {
+		return nil, fmt.Errorf("election: renew interval must be shorter than lease ttl")
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = opts.RenewInterval
+	}
+	if opts.Table == "" {
+		opts.Table = DefaultTable
+	}
+	if !grds.ValidIdentifier(opts.Table) {
+		return nil, fmt.Errorf("election: %w: %q", grds.ErrInvalidIdentifier, opts.Table)
+	}
+
+	e := &Elector{client: client, opts: opts}
+	if opts.Backend == BackendLease && !opts.SkipMigrate {
+		err := client.DB().Table(opts.Table).AutoMigrate(&Lease{})
+		if err != nil {
+			return nil, fmt.Errorf("election: migrate %s: %w", opts.Table, err)
+		}
+	}
+	return e, nil
+}
+
+// Identity 当前副本标识
+func (e *Elector) Identity() string {
+	return e.opts.Identity
+}
+
+// IsLeader 当前是否为 leader
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Leader 查询当前 leader 的标识，没有有效租约时返回空字符串（仅租约后端）
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	if e.opts.Backend != BackendLease {
+		return "", fmt.Errorf("election: leader lookup requires the lease backend")
+	}
+	var holders []string
+	err := e.db(ctx).Where("name = ? AND expires_at > NOW(6)", e.opts.Name).Pluck("holder", &holders).Error
+	if err != nil || len(holders) == 0 {
+		return "", err
+	}
+	return holders[0], nil
+}
+
+// Run 参与选举直到 ctx 取消；取消时卸任并释放租约或锁，然后返回 nil
+func (e *Elector) Run(ctx context.Context) error {
+	defer e.running.Wait()
+	if e.opts.Backend == BackendLock {
+		return e.runLock(ctx)
+	}
+	return e.runLease(ctx)
+}
+
+// runLease 租约后端：竞选、续约，本地计时的租约到期前续约失败则卸任
+func (e *Elector) runLease(ctx context.Context) error {
+	var renewedAt time.Time // 最近一次成功续约前的本地时间
+	// 在租约到期前留出一个续约间隔的余量卸任
+	safeTTL := e.opts.LeaseTTL - e.opts.RenewInterval
+
+	for {
+		// leader 的续约必须在本地租约到期前返回，数据库卡住时按超时处理并卸任
+		timeout := e.opts.LeaseTTL
+		if e.IsLeader() {
+			if timeout = safeTTL - time.Since(renewedAt); timeout <= 0 {
+				e.demote()
+				timeout = e.opts.LeaseTTL
+			}
+		}
+		start := time.Now()
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		ok, err := e.acquire(callCtx, !e.IsLeader())
+		cancel()
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				break
+			}
+			e.report(err)
+			if e.IsLeader() && time.Since(renewedAt) >= safeTTL {
+				e.demote()
+			}
+		case ok:
+			renewedAt = start
+			if !e.IsLeader() {
+				e.elect(ctx)
+			}
+		default:
+			if e.IsLeader() {
+				e.demote()
+			}
+		}
+
+		interval := e.opts.RetryInterval
+		if e.IsLeader() {
+			interval = e.opts.RenewInterval
+			if remaining := safeTTL - time.Since(renewedAt); remaining < interval {
+				interval = remaining
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				e.demote()
+				e.resign()
+			}
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// acquire 获取或续约租约，返回是否持有租约；ensure 为 true 时先确保租约行存在
+func (e *Elector) acquire(ctx context.Context, ensure bool) (bool, error) {
+	ttl := e.opts.LeaseTTL.Microseconds()
+	if ensure {
+		// 租约行不存在时先创建一个已过期的租约
+		err := e.db(ctx).Exec(
+			"INSERT IGNORE INTO `"+e.opts.Table+"` (`name`, `holder`, `expires_at`) VALUES (?, '', NOW(6))",
+			e.opts.Name,
+		).Error
+		if err != nil {
+			return false, err
+		}
+	}
+
+	result := e.db(ctx).Exec(
+		"UPDATE `"+e.opts.Table+"` SET `holder` = ?, `expires_at` = NOW(6) + INTERVAL ? MICROSECOND "+
+			"WHERE `name` = ? AND (`holder` = ? OR `expires_at` <= NOW(6))",
+		e.opts.Identity, ttl, e.opts.Name, e.opts.Identity,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// resign 释放租约，让其他副本立即当选
+func (e *Elector) resign() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.RenewInterval)
+	defer cancel()
+	err := e.db(ctx).Exec(
+		"UPDATE `"+e.opts.Table+"` SET `holder` = '', `expires_at` = NOW(6) WHERE `name` = ? AND `holder` = ?",
+		e.opts.Name, e.opts.Identity,
+	).Error
+	if err != nil {
+		e.report(err)
+	}
+}
+
+// runLock 锁后端：持有命名锁即为 leader，锁丢失时卸任
+//
+// 连接断开后其他副本可以立即取得锁，而本副本最多在 grds.AdvisoryLockCheckInterval 之后才卸任，
+// 两个 leader 可能重叠这段时间；需要严格互斥时使用租约后端。
+func (e *Elector) runLock(ctx context.Context) error {
+	name := "election:" + e.opts.Name
+	for {
+		lock, err := e.client.AdvisoryLock(ctx, name, 0)
+		if err == nil {
+			e.elect(ctx)
+			select {
+			case <-ctx.Done():
+			case <-lock.Lost():
+			}
+			e.demote()
+			unlockCtx, cancel := context.WithTimeout(context.Background(), e.opts.RenewInterval)
+			_ = lock.Unlock(unlockCtx)
+			cancel()
+		} else if !errors.Is(err, grds.ErrLockTimeout) && ctx.Err() == nil {
+			e.report(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.opts.RetryInterval):
+		}
+	}
+}
+
+// elect 当选
+func (e *Elector) elect(parent context.Context) {
+	// 等待上一任期的 OnElected 返回，避免同一进程内重叠执行
+	e.running.Wait()
+
+	ctx, cancel := context.WithCancel(parent)
+	e.mu.Lock()
+	e.leader = true
+	e.cancel = cancel
+	e.mu.Unlock()
+
+	if e.opts.OnElected != nil {
+		e.running.Add(1)
+		go func() {
+			defer e.running.Done()
+			e.opts.OnElected(ctx)
+		}()
+	}
+}
+
+// demote 卸任
+func (e *Elector) demote() {
+	e.mu.Lock()
+	if !e.leader {
+		e.mu.Unlock()
+		return
+	}
+	e.leader = false
+	cancel := e.cancel
+	e.cancel = nil
+	e.mu.Unlock()
+
+	cancel()
+	if e.opts.OnDemoted != nil {
+		e.opts.OnDemoted()
+	}
+}
+
+// db 返回租约表的查询
+func (e *Elector) db(ctx context.Context) *gorm.DB {
+	return e.client.DB().WithContext(ctx).Table(e.opts.Table)
+}
+
+// report 报告错误
+func (e *Elector) report(err error) {
+	if e.opts.OnError != nil {
+		e.opts.OnError(err)
+	}
+}
+
+// defaultIdentity 生成副本标识
+func defaultIdentity() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("election: generate identity: %w", err)
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b)), nil
+}
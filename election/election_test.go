@@ -0,0 +1,241 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nicexiaonie/grds"
+	"github.com/nicexiaonie/grds/internal/dbtest"
+)
+
+const (
+	ensureSQL  = "INSERT IGNORE INTO `grds_leases` (`name`, `holder`, `expires_at`) VALUES (?, '', NOW(6))"
+	acquireSQL = "UPDATE `grds_leases` SET `holder` = ?, `expires_at` = NOW(6) + INTERVAL ? MICROSECOND " +
+		"WHERE `name` = ? AND (`holder` = ? OR `expires_at` <= NOW(6))"
+	resignSQL = "UPDATE `grds_leases` SET `holder` = '', `expires_at` = NOW(6) WHERE `name` = ? AND `holder` = ?"
+)
+
+// terms 记录当选和卸任的时间
+type terms struct {
+	mu      sync.Mutex
+	elected []time.Time
+	demoted []time.Time
+	changed chan struct{}
+}
+
+func newTerms() *terms {
+	return &terms{changed: make(chan struct{}, 16)}
+}
+
+func (tr *terms) options(opts Options) Options {
+	opts.OnElected = func(ctx context.Context) {
+		tr.mu.Lock()
+		tr.elected = append(tr.elected, time.Now())
+		tr.mu.Unlock()
+		tr.changed <- struct{}{}
+	}
+	opts.OnDemoted = func() {
+		tr.mu.Lock()
+		tr.demoted = append(tr.demoted, time.Now())
+		tr.mu.Unlock()
+		tr.changed <- struct{}{}
+	}
+	return opts
+}
+
+// wait 等待下一次当选或卸任
+func (tr *terms) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-tr.changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for election change")
+	}
+}
+
+// newTestElector 创建由 sqlmock 驱动的选举参与者，不自动建表
+func newTestElector(t *testing.T, opts Options) (*Elector, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.Mock(t)
+	client, err := grds.NewClientFromDB(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Name = "cron"
+	opts.Identity = "me"
+	opts.SkipMigrate = true
+	e, err := New(client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, mock
+}
+
+// expectElected 期望一次成功的竞选
+func expectElected(mock sqlmock.Sqlmock, ttl time.Duration) {
+	mock.ExpectExec(ensureSQL).WithArgs("cron").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(acquireSQL).WithArgs("me", ttl.Microseconds(), "cron", "me").WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// run 在后台运行选举，返回停止函数
+func run(e *Elector) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = e.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestLeaseDemotedBeforeExpiry(t *testing.T) {
+	const ttl, renew = 300 * time.Millisecond, 100 * time.Millisecond
+	safeTTL := ttl - renew
+	tests := []struct {
+		name  string
+		renew func(q *sqlmock.ExpectedExec)
+	}{
+		// 续约失败：本地计时超过 safeTTL 后卸任
+		{"renew fails", func(q *sqlmock.ExpectedExec) {
+			q.WillReturnError(errors.New("connection refused"))
+		}},
+		// 续约卡住：按剩余时间超时，不能等到租约过期
+		{"renew hangs", func(q *sqlmock.ExpectedExec) {
+			q.WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(0, 1))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTerms()
+			var reported int32
+			opts := tr.options(Options{LeaseTTL: ttl, RenewInterval: renew, RetryInterval: time.Second})
+			opts.OnError = func(error) { atomic.AddInt32(&reported, 1) }
+			e, mock := newTestElector(t, opts)
+			expectElected(mock, ttl)
+			tt.renew(mock.ExpectExec(acquireSQL).WithArgs("me", ttl.Microseconds(), "cron", "me"))
+			// 之后的续约都没有对应的期望，sqlmock 返回错误
+
+			stop := run(e)
+			defer stop()
+			tr.wait(t)
+			tr.wait(t)
+
+			tr.mu.Lock()
+			held := tr.demoted[0].Sub(tr.elected[0])
+			tr.mu.Unlock()
+			if held < safeTTL-10*time.Millisecond || held >= ttl {
+				t.Errorf("demoted after %v, want between %v and %v", held, safeTTL, ttl)
+			}
+			if e.IsLeader() {
+				t.Error("still leader after demotion")
+			}
+			if atomic.LoadInt32(&reported) == 0 {
+				t.Error("renewal failure not reported")
+			}
+		})
+	}
+}
+
+func TestLeaseResignOnShutdown(t *testing.T) {
+	const ttl = 3 * time.Second
+	tr := newTerms()
+	e, mock := newTestElector(t, tr.options(Options{LeaseTTL: ttl, RenewInterval: time.Second}))
+	expectElected(mock, ttl)
+	mock.ExpectExec(resignSQL).WithArgs("cron", "me").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stop := run(e)
+	tr.wait(t)
+	if !e.IsLeader() {
+		t.Fatal("not leader after election")
+	}
+	stop()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.demoted) != 1 || e.IsLeader() {
+		t.Errorf("demoted %d times, leader %v, want demoted once", len(tr.demoted), e.IsLeader())
+	}
+}
+
+func TestLeaseLost(t *testing.T) {
+	const ttl, renew = time.Second, 50 * time.Millisecond
+	tr := newTerms()
+	e, mock := newTestElector(t, tr.options(Options{LeaseTTL: ttl, RenewInterval: renew, RetryInterval: time.Second}))
+	expectElected(mock, ttl)
+	// 租约已被其他副本取得：续约没有更新任何行
+	mock.ExpectExec(acquireSQL).WithArgs("me", ttl.Microseconds(), "cron", "me").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	stop := run(e)
+	defer stop()
+	tr.wait(t)
+	tr.wait(t)
+	if e.IsLeader() {
+		t.Error("still leader after losing the lease")
+	}
+}
+
+func TestElectWaitsForPreviousTerm(t *testing.T) {
+	var active, overlap int32
+	release := make(chan struct{})
+	e, _ := newTestElector(t, Options{
+		OnElected: func(ctx context.Context) {
+			if atomic.AddInt32(&active, 1) > 1 {
+				atomic.StoreInt32(&overlap, 1)
+			}
+			defer atomic.AddInt32(&active, -1)
+			<-ctx.Done()
+			// 卸任后仍在收尾
+			<-release
+		},
+	})
+
+	ctx := context.Background()
+	e.elect(ctx)
+	e.demote()
+
+	elected := make(chan struct{})
+	go func() {
+		e.elect(ctx)
+		close(elected)
+	}()
+	select {
+	case <-elected:
+		t.Fatal("second term started before the first OnElected returned")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-elected
+	e.demote()
+	e.running.Wait()
+	if atomic.LoadInt32(&overlap) != 0 {
+		t.Error("OnElected calls overlapped")
+	}
+}
+
+func TestLockBackend(t *testing.T) {
+	tr := newTerms()
+	e, mock := newTestElector(t, tr.options(Options{Backend: BackendLock, RetryInterval: time.Second}))
+	mock.ExpectQuery("SELECT GET_LOCK(?, ?)").WithArgs("election:cron", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"got"}).AddRow(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK(?)").WithArgs("election:cron").
+		WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+
+	stop := run(e)
+	tr.wait(t)
+	if !e.IsLeader() {
+		t.Fatal("not leader while holding the lock")
+	}
+	stop()
+	if e.IsLeader() {
+		t.Error("still leader after shutdown")
+	}
+}
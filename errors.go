@@ -0,0 +1,266 @@
+package grds
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// MySQL 错误码
+const (
+	mysqlErrDupKey                   = 1022 // ER_DUP_KEY
+	mysqlErrConCount                 = 1040 // ER_CON_COUNT_ERROR：连接数过多
+	mysqlErrServerShutdown           = 1053 // ER_SERVER_SHUTDOWN
+	mysqlErrDupEntry                 = 1062 // ER_DUP_ENTRY
+	mysqlErrNoReferencedRow          = 1216 // ER_NO_REFERENCED_ROW
+	mysqlErrRowIsReferenced          = 1217 // ER_ROW_IS_REFERENCED
+	mysqlErrLockWaitTimeout          = 1205 // ER_LOCK_WAIT_TIMEOUT
+	mysqlErrLockDeadlock             = 1213 // ER_LOCK_DEADLOCK
+	mysqlErrOptionPrevents           = 1290 // ER_OPTION_PREVENTS_STATEMENT：--read-only 等选项禁止执行
+	mysqlErrRowIsReferenced2         = 1451 // ER_ROW_IS_REFERENCED_2
+	mysqlErrNoReferencedRow2         = 1452 // ER_NO_REFERENCED_ROW_2
+	mysqlErrDupEntryWithKeyName      = 1586 // ER_DUP_ENTRY_WITH_KEY_NAME
+	mysqlErrReadOnlyTransaction      = 1792 // ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION
+	mysqlErrReadOnlyMode             = 1836 // ER_READ_ONLY_MODE
+	mysqlErrConnectionKilled         = 1927 // ER_CONNECTION_KILLED
+	mysqlErrLockNoWait               = 3572 // ER_LOCK_NOWAIT：NOWAIT 时行已被锁定
+	mysqlErrClientInteractionTimeout = 4031 // ER_CLIENT_INTERACTION_TIMEOUT：空闲连接被服务端断开
+)
+
+var (
+	// ErrLockNotAvailable NOWAIT 加锁失败：行已被其他事务锁定
+	ErrLockNotAvailable = errors.New("lock not available")
+	// ErrDuplicateKey 违反唯一约束
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrDeadlock 死锁，事务已被回滚，可以整体重试
+	ErrDeadlock = errors.New("deadlock")
+	// ErrLockWaitTimeout 等待行锁超时，默认只回滚当前语句
+	ErrLockWaitTimeout = errors.New("lock wait timeout")
+	// ErrForeignKeyViolation 违反外键约束
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrReadOnly 实例或事务只读，通常是连到了从库或主从切换中的旧主库
+	ErrReadOnly = errors.New("read only")
+	// ErrConnection 连接错误：连接断开、被杀死或连接数过多
+	ErrConnection = errors.New("connection error")
+)
+
+// classifiedError 把驱动错误归类到哨兵错误，同时保留原始错误
+//
+// errors.Is(err, ErrLockNotAvailable) 匹配分类，errors.As(err, &mysqlErr) 仍可取到驱动错误。
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+// Error 返回分类和原始错误信息
+func (e *classifiedError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+// Is 匹配分类
+func (e *classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Unwrap 返回原始错误
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// translateError 归类 MySQL 错误，无法归类时原样返回
+func translateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if err == nil || !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	kind := mysqlErrorKind(mysqlErr)
+	if kind == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &classifiedError{kind: kind, err: err}
+}
+
+// mysqlErrorKind 按错误码归类，无法归类时返回 nil
+func mysqlErrorKind(mysqlErr *mysql.MySQLError) error {
+	switch mysqlErr.Number {
+	case mysqlErrLockNoWait:
+		return ErrLockNotAvailable
+	case mysqlErrDupEntry, mysqlErrDupEntryWithKeyName, mysqlErrDupKey:
+		return ErrDuplicateKey
+	case mysqlErrLockDeadlock:
+		return ErrDeadlock
+	case mysqlErrLockWaitTimeout:
+		return ErrLockWaitTimeout
+	case mysqlErrRowIsReferenced, mysqlErrRowIsReferenced2, mysqlErrNoReferencedRow, mysqlErrNoReferencedRow2:
+		return ErrForeignKeyViolation
+	case mysqlErrReadOnlyTransaction, mysqlErrReadOnlyMode:
+		return ErrReadOnly
+	case mysqlErrOptionPrevents:
+		if strings.Contains(mysqlErr.Message, "read-only") {
+			return ErrReadOnly
+		}
+	case mysqlErrConCount, mysqlErrServerShutdown, mysqlErrConnectionKilled, mysqlErrClientInteractionTimeout:
+		return ErrConnection
+	}
+	return nil
+}
+
+// ==================== 错误判断 ====================
+
+// IsDuplicateKey 是否违反唯一约束，返回冲突的索引名（如 PRIMARY、uk_email），无法解析时为空
+//
+//	if key, ok := grds.IsDuplicateKey(err); ok && key == "uk_email" { ... }
+func IsDuplicateKey(err error) (string, bool) {
+	if !errors.Is(translateError(err), ErrDuplicateKey) {
+		return "", false
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return "", true
+	}
+	// Duplicate entry 'x' for key 'users.uk_email'，MySQL 8.0.19 起索引名带表名前缀
+	msg := mysqlErr.Message
+	i := strings.LastIndex(msg, "for key '")
+	if i < 0 {
+		return "", true
+	}
+	key := strings.TrimSuffix(msg[i+len("for key '"):], "'")
+	if j := strings.LastIndexByte(key, '.'); j >= 0 {
+		key = key[j+1:]
+	}
+	return key, true
+}
+
+// IsDeadlock 是否为死锁
+func IsDeadlock(err error) bool {
+	return errors.Is(translateError(err), ErrDeadlock)
+}
+
+// IsLockWaitTimeout 是否为等待行锁超时
+func IsLockWaitTimeout(err error) bool {
+	return errors.Is(translateError(err), ErrLockWaitTimeout)
+}
+
+// IsForeignKeyViolation 是否违反外键约束
+func IsForeignKeyViolation(err error) bool {
+	return errors.Is(translateError(err), ErrForeignKeyViolation)
+}
+
+// IsNotFound 是否为记录不存在（gorm.ErrRecordNotFound）
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// IsReadOnly 是否因实例或事务只读而失败
+func IsReadOnly(err error) bool {
+	return errors.Is(translateError(err), ErrReadOnly)
+}
+
+// IsConnectionError 是否为连接错误，包括服务端断开、网络错误和连接数过多
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(translateError(err), ErrConnection) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// ==================== 查询错误 ====================
+
+// Error 查询构建器执行语句失败时返回的错误，记录操作、表名和 SQL
+//
+// 原始错误已按 MySQL 错误码归类，errors.Is(err, grds.ErrDeadlock)、errors.Is(err, gorm.ErrRecordNotFound)
+// 和 errors.As(err, &mysqlErr) 都可以穿过 Error 使用。
+type Error struct {
+	Op    string // 操作，为方法名的 snake_case 形式，如 find、create、update_columns
+	Table string // 表名
+	SQL   string // 执行的 SQL（参数为占位符），语句生成前失败时为空
+	Err   error  // 原始错误
+}
+
+// Error 返回操作、表名和原始错误信息
+func (e *Error) Error() string {
+	if e.Table == "" {
+		return e.Op + ": " + e.Err.Error()
+	}
+	return e.Op + " " + e.Table + ": " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError 把 tx 上的错误包装为 *Error
+func wrapError(op string, tx *gorm.DB) error {
+	return newError(op, tx, tx.Error)
+}
+
+// newError 包装 err，已经是 *Error 时原样返回
+func newError(op string, tx *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	stmt := tx.Statement
+	table := stmt.Table
+	if table == "" && stmt.Schema != nil {
+		table = stmt.Schema.Table
+	}
+	sql := stmt.SQL.String()
+	if v, ok := stmt.Settings.Load(errorSQLKey); ok && sql == "" {
+		sql = v.(string)
+	}
+	return &Error{Op: op, Table: table, SQL: sql, Err: translateError(err)}
+}
+
+// errorSQLKey 出错语句的 SQL，GORM 在回调结束后会清空 Statement.SQL
+const errorSQLKey = "grds:error_sql"
+
+// translateErrorCallback 在语句执行后归类错误，并记录出错的 SQL
+//
+// Settings 会被同一查询链后续的执行继承，成功时删除，避免把上一条语句的 SQL 附加到新的错误上。
+func translateErrorCallback(db *gorm.DB) {
+	if db.Error == nil {
+		db.Statement.Settings.Delete(errorSQLKey)
+		return
+	}
+	db.Error = translateError(db.Error)
+	db.Statement.Settings.Store(errorSQLKey, db.Statement.SQL.String())
+}
+
+// registerErrorTranslation 注册错误归类回调
+func registerErrorTranslation(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("grds:translate_error", translateErrorCallback); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("grds:translate_error", translateErrorCallback); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("grds:translate_error", translateErrorCallback); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("grds:translate_error", translateErrorCallback); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("grds:translate_error", translateErrorCallback); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("grds:translate_error", translateErrorCallback)
+}
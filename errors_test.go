@@ -0,0 +1,103 @@
+package grds
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		number  uint16
+		message string
+		want    error
+	}{
+		{1062, "Duplicate entry 'a' for key 'users.uk_email'", ErrDuplicateKey},
+		{1213, "Deadlock found when trying to get lock", ErrDeadlock},
+		{1205, "Lock wait timeout exceeded", ErrLockWaitTimeout},
+		{1452, "Cannot add or update a child row", ErrForeignKeyViolation},
+		{1290, "The MySQL server is running with the --read-only option", ErrReadOnly},
+		{1290, "The MySQL server is running with the --skip-grant-tables option", nil},
+		{1792, "Cannot execute statement in a READ ONLY transaction.", ErrReadOnly},
+		{3572, "Statement aborted because lock(s) could not be acquired immediately and NOWAIT is set.", ErrLockNotAvailable},
+		{1927, "Connection was killed", ErrConnection},
+		{1064, "You have an error in your SQL syntax", nil},
+	}
+	for _, tt := range tests {
+		raw := &mysql.MySQLError{Number: tt.number, Message: tt.message}
+		err := translateError(fmt.Errorf("wrapped: %w", raw))
+		for _, kind := range []error{ErrDuplicateKey, ErrDeadlock, ErrLockWaitTimeout, ErrForeignKeyViolation, ErrReadOnly, ErrLockNotAvailable, ErrConnection} {
+			if got := errors.Is(err, kind); got != (kind == tt.want) {
+				t.Errorf("%d %q: errors.Is(%v) = %v", tt.number, tt.message, kind, got)
+			}
+		}
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr != raw {
+			t.Errorf("%d: driver error not preserved", tt.number)
+		}
+	}
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	tests := []struct {
+		err    error
+		key    string
+		wantOK bool
+	}{
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'users.uk_email'"}, "uk_email", true},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, "PRIMARY", true},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, "", true},
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock"}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		if key, ok := IsDuplicateKey(tt.err); key != tt.key || ok != tt.wantOK {
+			t.Errorf("IsDuplicateKey(%v) = %q, %v; want %q, %v", tt.err, key, ok, tt.key, tt.wantOK)
+		}
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, err := range []error{driver.ErrBadConn, mysql.ErrInvalidConn, &mysql.MySQLError{Number: 1040}} {
+		if !IsConnectionError(fmt.Errorf("op: %w", err)) {
+			t.Errorf("IsConnectionError(%v) = false", err)
+		}
+	}
+	if IsConnectionError(nil) || IsConnectionError(errors.New("other")) {
+		t.Error("unexpected connection error")
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	e := &Error{Op: "find", Table: "users", Err: gorm.ErrRecordNotFound}
+	if e.Error() != "find users: record not found" || !IsNotFound(e) {
+		t.Errorf("unexpected %q", e.Error())
+	}
+	if (&Error{Op: "exec", Err: errors.New("x")}).Error() != "exec: x" {
+		t.Error("unexpected message without table")
+	}
+}
+
+func TestErrorSQLNotReused(t *testing.T) {
+	c := newTestClient(t)
+	db := c.DB().Table("users")
+
+	db.Error = errors.New("first")
+	db.Statement.SQL.WriteString("SELECT 1")
+	translateErrorCallback(db)
+	if err := newError("find", db, db.Error).(*Error); err.SQL != "SELECT 1" {
+		t.Fatalf("SQL = %q, want SELECT 1", err.SQL)
+	}
+
+	// 同一查询链上成功执行后，新的错误不应带上之前的 SQL
+	db.Statement.SQL.Reset()
+	db.Error = nil
+	translateErrorCallback(db)
+	if err := newError("count", db, errors.New("second")).(*Error); err.SQL != "" {
+		t.Errorf("SQL = %q, want empty", err.SQL)
+	}
+}
@@ -0,0 +1,210 @@
+package grds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// SQLOp ToSQL 生成的语句类型
+type SQLOp string
+
+const (
+	SQLFind   SQLOp = "find"   // SELECT
+	SQLCount  SQLOp = "count"  // SELECT count(*)
+	SQLUpdate SQLOp = "update" // UPDATE，values 为 (column, value) 或 map/结构体
+	SQLDelete SQLOp = "delete" // DELETE（软删除表为 UPDATE）
+)
+
+// ToSQL 返回查询链将要执行的 SQL 和参数，不访问数据库，也不改变当前查询链
+//
+//	sql, args, err := grds.Table("users").WhereEq("status", 1).ToSQL(grds.SQLFind)
+//	sql, args, err := grds.Table("users").WhereEq("id", 1).ToSQL(grds.SQLUpdate, "name", "tom")
+func (qb *QueryBuilder) ToSQL(op SQLOp, values ...interface{}) (string, []interface{}, error) {
+	tx := qb.db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})
+	switch op {
+	case SQLFind:
+		tx = tx.Find(qb.dryRunDest(true))
+	case SQLCount:
+		var count int64
+		tx = tx.Count(&count)
+	case SQLUpdate:
+		switch {
+		case len(values) == 2:
+			column, ok := values[0].(string)
+			if !ok {
+				return "", nil, fmt.Errorf("to sql: update column must be a string, got %T", values[0])
+			}
+			tx = tx.Update(column, values[1])
+		case len(values) == 1:
+			tx = tx.Updates(values[0])
+		default:
+			return "", nil, fmt.Errorf("to sql: update expects (column, value) or a map/struct")
+		}
+	case SQLDelete:
+		tx = tx.Delete(qb.dryRunDest(false))
+	default:
+		return "", nil, fmt.Errorf("to sql: unsupported operation %q", op)
+	}
+
+	if tx.Error != nil {
+		return "", nil, tx.Error
+	}
+	return tx.Statement.SQL.String(), tx.Statement.Vars, nil
+}
+
+// dryRunDest 生成演练用的目标：有模型时使用模型类型，否则使用 map
+func (qb *QueryBuilder) dryRunDest(slice bool) interface{} {
+	model := qb.db.Statement.Model
+	if model == nil {
+		if slice {
+			return &[]map[string]interface{}{}
+		}
+		return map[string]interface{}{}
+	}
+
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	if slice {
+		return reflect.New(reflect.SliceOf(typ)).Interface()
+	}
+	return reflect.New(typ).Interface()
+}
+
+// ==================== EXPLAIN ====================
+
+// ExplainTable 执行计划中访问的一个表
+type ExplainTable struct {
+	Name         string   // 表名或别名
+	AccessType   string   // 访问类型：ALL、index、range、ref、eq_ref、const 等
+	PossibleKeys []string // 可用的索引
+	Key          string   // 实际使用的索引
+	Rows         int64    // 预估扫描行数
+	Filtered     float64  // 条件过滤后剩余行的百分比
+}
+
+// ExplainPlan 解析后的执行计划
+type ExplainPlan struct {
+	SQL            string          // 被分析的 SQL
+	Vars           []interface{}   // SQL 参数
+	Cost           float64         // 优化器估算的总成本
+	Tables         []ExplainTable  // 访问的表
+	FullScan       bool            // 存在全表扫描（access_type = ALL）
+	Filesort       bool            // 使用了文件排序
+	TemporaryTable bool            // 使用了临时表
+	JSON           json.RawMessage // EXPLAIN FORMAT=JSON 的原始输出
+}
+
+// FullScanTables 全表扫描的表
+func (p *ExplainPlan) FullScanTables() []string {
+	var names []string
+	for _, t := range p.Tables {
+		if t.AccessType == "ALL" {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// Explain 对查询执行 EXPLAIN FORMAT=JSON 并解析执行计划
+//
+//	plan, err := grds.Table("orders").WhereEq("status", 1).OrderByDesc("id").Explain(ctx)
+//	if plan.FullScan || plan.Filesort { ... }
+func (qb *QueryBuilder) Explain(ctx context.Context) (*ExplainPlan, error) {
+	query, vars, err := qb.ToSQL(SQLFind)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw string
+	tx := qb.db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	tx = tx.Raw("EXPLAIN FORMAT=JSON "+query, vars...)
+	if err := tx.Row().Scan(&raw); err != nil {
+		return nil, &Error{Op: "explain", Table: qb.db.Statement.Table, SQL: query, Err: translateError(err)}
+	}
+	return parseExplain(query, vars, []byte(raw))
+}
+
+// parseExplain 解析 EXPLAIN FORMAT=JSON 的输出
+func parseExplain(query string, vars []interface{}, raw []byte) (*ExplainPlan, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("explain: parse plan: %w", err)
+	}
+
+	plan := &ExplainPlan{SQL: query, Vars: vars, JSON: raw}
+	if block, ok := doc["query_block"].(map[string]interface{}); ok {
+		if costInfo, ok := block["cost_info"].(map[string]interface{}); ok {
+			plan.Cost = explainNumber(costInfo["query_cost"])
+		}
+	}
+	walkExplain(doc, plan)
+	plan.FullScan = len(plan.FullScanTables()) > 0
+	return plan, nil
+}
+
+// walkExplain 递归遍历计划节点，收集表访问和排序、临时表标记
+func walkExplain(node interface{}, plan *ExplainPlan) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if b, ok := v["using_filesort"].(bool); ok && b {
+			plan.Filesort = true
+		}
+		if b, ok := v["using_temporary_table"].(bool); ok && b {
+			plan.TemporaryTable = true
+		}
+		if table, ok := v["table"].(map[string]interface{}); ok {
+			plan.Tables = append(plan.Tables, explainTable(table))
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkExplain(v[k], plan)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkExplain(child, plan)
+		}
+	}
+}
+
+// explainTable 解析 table 节点
+func explainTable(node map[string]interface{}) ExplainTable {
+	t := ExplainTable{
+		Rows:     int64(explainNumber(node["rows_examined_per_scan"])),
+		Filtered: explainNumber(node["filtered"]),
+	}
+	t.Name, _ = node["table_name"].(string)
+	t.AccessType, _ = node["access_type"].(string)
+	t.Key, _ = node["key"].(string)
+	if keys, ok := node["possible_keys"].([]interface{}); ok {
+		for _, k := range keys {
+			if s, ok := k.(string); ok {
+				t.PossibleKeys = append(t.PossibleKeys, s)
+			}
+		}
+	}
+	return t
+}
+
+// explainNumber MySQL 的计划中数字可能是数值也可能是字符串
+func explainNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package grds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExplain(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want ExplainPlan
+	}{
+		{
+			"single table",
+			`{"query_block": {"select_id": 1, "cost_info": {"query_cost": "1.20"},
+				"table": {"table_name": "users", "access_type": "ALL", "possible_keys": ["idx_status"],
+					"rows_examined_per_scan": 10, "filtered": "10.00"}}}`,
+			ExplainPlan{Cost: 1.2, FullScan: true, Tables: []ExplainTable{
+				{Name: "users", AccessType: "ALL", PossibleKeys: []string{"idx_status"}, Rows: 10, Filtered: 10},
+			}},
+		},
+		{
+			"join with filesort",
+			`{"query_block": {"cost_info": {"query_cost": "8.50"},
+				"ordering_operation": {"using_filesort": true, "using_temporary_table": true,
+					"nested_loop": [
+						{"table": {"table_name": "o", "access_type": "ref", "key": "idx_user", "rows_examined_per_scan": "3", "filtered": 100}},
+						{"table": {"table_name": "u", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1, "filtered": 100}}
+					]}}}`,
+			ExplainPlan{Cost: 8.5, Filesort: true, TemporaryTable: true, Tables: []ExplainTable{
+				{Name: "o", AccessType: "ref", Key: "idx_user", Rows: 3, Filtered: 100},
+				{Name: "u", AccessType: "eq_ref", Key: "PRIMARY", Rows: 1, Filtered: 100},
+			}},
+		},
+		{
+			"no table",
+			`{"query_block": {"select_id": 1, "message": "No tables used"}}`,
+			ExplainPlan{},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseExplain("SELECT 1", nil, []byte(tt.raw))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		tt.want.SQL, tt.want.JSON = "SELECT 1", got.JSON
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+
+	if _, err := parseExplain("SELECT 1", nil, []byte("not json")); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name   string
+		qb     *QueryBuilder
+		op     SQLOp
+		values []interface{}
+		want   string
+		vars   []interface{}
+	}{
+		{"find", c.Table("users").WhereEq("status", 1), SQLFind, nil,
+			"SELECT * FROM `users` WHERE `status` = ?", []interface{}{1}},
+		{"count", c.Table("users").WhereEq("status", 1), SQLCount, nil,
+			"SELECT count(*) FROM `users` WHERE `status` = ?", []interface{}{1}},
+		{"update column", c.Table("users").WhereEq("id", 1), SQLUpdate, []interface{}{"name", "tom"},
+			"UPDATE `users` SET `name`=? WHERE `id` = ?", []interface{}{"tom", 1}},
+		{"update map", c.Table("users").WhereEq("id", 1), SQLUpdate, []interface{}{map[string]interface{}{"name": "tom"}},
+			"UPDATE `users` SET `name`=? WHERE `id` = ?", []interface{}{"tom", 1}},
+		{"delete", c.Table("users").WhereEq("id", 1), SQLDelete, nil,
+			"DELETE FROM `users` WHERE `id` = ?", []interface{}{1}},
+	}
+	for _, tt := range tests {
+		sql, vars := toSQL(t, tt.qb, tt.op, tt.values...)
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+
+	for _, values := range [][]interface{}{{1, "x"}, {}} {
+		if _, _, err := c.Table("users").WhereEq("id", 1).ToSQL(SQLUpdate, values...); err == nil {
+			t.Errorf("ToSQL(update, %v) should fail", values)
+		}
+	}
+	if _, _, err := c.Table("users").ToSQL("replace"); err == nil {
+		t.Error("ToSQL(replace) should fail")
+	}
+}
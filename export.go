@@ -0,0 +1,362 @@
+package grds
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportFormat 导出格式
+type ExportFormat string
+
+const (
+	ExportCSV   ExportFormat = "csv"   // RFC 4180 CSV，第一行为表头
+	ExportTSV   ExportFormat = "tsv"   // 制表符分隔，按 LOAD DATA 的默认规则转义，第一行为表头
+	ExportJSONL ExportFormat = "jsonl" // 每行一个 JSON 对象，键的顺序与列顺序一致
+)
+
+// BinaryEncoding 二进制列的文本编码
+type BinaryEncoding string
+
+const (
+	BinaryBase64 BinaryEncoding = "base64"
+	BinaryHex    BinaryEncoding = "hex"
+)
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	Columns        []string          // 导出的列，为空时使用查询链上的 Select，否则为 *
+	Headers        map[string]string // 结果列名到表头（JSON 键）的映射，用于重命名
+	NullValue      string            // CSV/TSV 中 NULL 的表示，默认 CSV 为空字符串，TSV 为 \N；JSONL 总是 null
+	TimeFormat     string            // 时间格式，默认 time.RFC3339Nano；未开启 parseTime 时按本地时区解析文本后格式化
+	BinaryEncoding BinaryEncoding    // 二进制列的编码，默认 base64
+	NoHeader       bool              // CSV/TSV 不输出表头
+}
+
+// Export 把查询结果流式写入 w，返回导出的行数
+//
+// 逐行读取和写入，内存占用与结果集大小无关。opts 为 nil 时使用默认选项。
+//
+//	n, err := grds.Table("orders").WhereEq("status", 1).Export(ctx, w, grds.ExportCSV, &grds.ExportOptions{
+//		Columns: []string{"id", "amount", "created_at"},
+//		Headers: map[string]string{"created_at": "time"},
+//	})
+func (qb *QueryBuilder) Export(ctx context.Context, w io.Writer, format ExportFormat, opts *ExportOptions) (int64, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	e, err := newExporter(w, format, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	tx := qb.db.Session(&gorm.Session{}).WithContext(ctx)
+	if len(opts.Columns) > 0 {
+		columns := make([]string, 0, len(opts.Columns))
+		for _, column := range opts.Columns {
+			col, err := qb.quoteColumn(column)
+			if err != nil {
+				return 0, newError("export", tx, err)
+			}
+			columns = append(columns, col)
+		}
+		tx = tx.Select(strings.Join(columns, ", "))
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		return 0, newError("export", tx, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, newError("export", tx, err)
+	}
+	if err := e.begin(columnTypes); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columnTypes))
+	ptrs := make([]interface{}, len(columnTypes))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, newError("export", tx, err)
+		}
+		if err := e.row(values); err != nil {
+			return n, fmt.Errorf("export: %w", err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, newError("export", tx, err)
+	}
+	if err := e.flush(); err != nil {
+		return n, fmt.Errorf("export: %w", err)
+	}
+	return n, nil
+}
+
+// columnKind 列值的输出方式
+type columnKind int
+
+const (
+	kindText   columnKind = iota // 字符串
+	kindNumber                   // 数字，JSONL 中不加引号
+	kindBinary                   // 二进制，按 BinaryEncoding 编码
+	kindTime                     // 日期时间，按 TimeFormat 格式化
+)
+
+// exportColumnKind 根据列类型确定输出方式
+func exportColumnKind(ct *sql.ColumnType) columnKind {
+	switch strings.TrimPrefix(strings.ToUpper(ct.DatabaseTypeName()), "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR", "DECIMAL", "FLOAT", "DOUBLE":
+		return kindNumber
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY", "BIT":
+		return kindBinary
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return kindTime
+	}
+	return kindText
+}
+
+// exporter 按格式输出表头和行
+type exporter struct {
+	format  ExportFormat
+	opts    *ExportOptions
+	null    string
+	kinds   []columnKind
+	headers []string
+	csv     *csv.Writer
+	buf     *bufio.Writer
+	record  []string
+}
+
+// newExporter 校验格式并创建输出器
+func newExporter(w io.Writer, format ExportFormat, opts *ExportOptions) (*exporter, error) {
+	e := &exporter{format: format, opts: opts, null: opts.NullValue}
+	switch format {
+	case ExportCSV:
+		e.csv = csv.NewWriter(w)
+	case ExportTSV:
+		e.buf = bufio.NewWriter(w)
+		if e.null == "" {
+			e.null = `\N`
+		}
+	case ExportJSONL:
+		e.buf = bufio.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("export: unsupported format %q", format)
+	}
+	switch opts.BinaryEncoding {
+	case "", BinaryBase64, BinaryHex:
+	default:
+		return nil, fmt.Errorf("export: unsupported binary encoding %q", opts.BinaryEncoding)
+	}
+	return e, nil
+}
+
+// begin 记录列信息并输出表头
+func (e *exporter) begin(columnTypes []*sql.ColumnType) error {
+	names := make([]string, len(columnTypes))
+	kinds := make([]columnKind, len(columnTypes))
+	for i, ct := range columnTypes {
+		names[i] = ct.Name()
+		kinds[i] = exportColumnKind(ct)
+	}
+	return e.beginColumns(names, kinds)
+}
+
+// beginColumns 按列名和输出方式输出表头
+func (e *exporter) beginColumns(names []string, kinds []columnKind) error {
+	e.kinds = kinds
+	e.headers = make([]string, len(names))
+	e.record = make([]string, len(names))
+	for i, name := range names {
+		e.headers[i] = name
+		if header, ok := e.opts.Headers[name]; ok {
+			e.headers[i] = header
+		}
+	}
+
+	if e.opts.NoHeader {
+		return nil
+	}
+	switch e.format {
+	case ExportCSV:
+		return e.csv.Write(e.headers)
+	case ExportTSV:
+		for i, header := range e.headers {
+			e.record[i] = escapeTSV(header)
+		}
+		return e.writeTSV()
+	}
+	return nil
+}
+
+// row 输出一行
+func (e *exporter) row(values []interface{}) error {
+	if e.format == ExportJSONL {
+		return e.writeJSON(values)
+	}
+	for i, value := range values {
+		text, ok := e.text(value, e.kinds[i])
+		switch {
+		case !ok:
+			text = e.null
+		case e.format == ExportTSV:
+			text = escapeTSV(text)
+		}
+		e.record[i] = text
+	}
+	if e.format == ExportCSV {
+		return e.csv.Write(e.record)
+	}
+	return e.writeTSV()
+}
+
+// writeTSV 输出一行 TSV
+func (e *exporter) writeTSV() error {
+	_, err := e.buf.WriteString(strings.Join(e.record, "\t") + "\n")
+	return err
+}
+
+// writeJSON 输出一行 JSON 对象，按列顺序写出键
+func (e *exporter) writeJSON(values []interface{}) error {
+	e.buf.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.headers[i])
+		e.buf.Write(key)
+		e.buf.WriteByte(':')
+
+		text, ok := e.text(value, e.kinds[i])
+		if !ok {
+			e.buf.WriteString("null")
+			continue
+		}
+		switch v := value.(type) {
+		case bool:
+			e.buf.WriteString(strconv.FormatBool(v))
+			continue
+		case int64, uint64, float64, float32:
+			e.buf.WriteString(text)
+			continue
+		}
+		if e.kinds[i] == kindNumber && json.Valid([]byte(text)) {
+			e.buf.WriteString(text)
+			continue
+		}
+		encoded, err := json.Marshal(text)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(encoded)
+	}
+	_, err := e.buf.WriteString("}\n")
+	return err
+}
+
+// text 把列值转换为文本，NULL 时返回 false
+func (e *exporter) text(value interface{}, kind columnKind) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		switch kind {
+		case kindBinary:
+			return e.encodeBinary(v), true
+		case kindTime:
+			return e.formatTimeText(string(v)), true
+		}
+		return string(v), true
+	case string:
+		if kind == kindTime {
+			return e.formatTimeText(v), true
+		}
+		return v, true
+	case time.Time:
+		return e.formatTime(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return fmt.Sprint(value), true
+}
+
+// formatTime 按 TimeFormat 格式化时间
+func (e *exporter) formatTime(t time.Time) string {
+	layout := e.opts.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	return t.Format(layout)
+}
+
+// mysqlTimeLayouts DATETIME/TIMESTAMP/DATE 的文本格式
+var mysqlTimeLayouts = []string{"2006-01-02 15:04:05.999999", "2006-01-02"}
+
+// formatTimeText 格式化未开启 parseTime 时以文本返回的时间，按本地时区解析；
+// 无法解析的值（如 0000-00-00）原样输出
+func (e *exporter) formatTimeText(text string) string {
+	for _, layout := range mysqlTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return e.formatTime(t)
+		}
+	}
+	return text
+}
+
+// encodeBinary 按选项编码二进制值
+func (e *exporter) encodeBinary(data []byte) string {
+	if e.opts.BinaryEncoding == BinaryHex {
+		return hex.EncodeToString(data)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// flush 写出缓冲区
+func (e *exporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return e.buf.Flush()
+}
+
+// tsvEscaper LOAD DATA 默认的转义规则（FIELDS ESCAPED BY '\\'）
+var tsvEscaper = strings.NewReplacer(
+	`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`,
+)
+
+// escapeTSV 转义 TSV 字段
+func escapeTSV(s string) string {
+	return tsvEscaper.Replace(s)
+}
@@ -0,0 +1,105 @@
+package grds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEscapeTSV(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{"a\tb", `a\tb`},
+		{"line\nbreak\r", `line\nbreak\r`},
+		{`back\slash`, `back\\slash`},
+		{"nul\x00", `nul\0`},
+	}
+	for _, tt := range tests {
+		if got := escapeTSV(tt.in); got != tt.want {
+			t.Errorf("escapeTSV(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExporterFormats(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	names := []string{"id", "name", "data", "created_at", "note"}
+	kinds := []columnKind{kindNumber, kindText, kindBinary, kindTime, kindText}
+	rows := [][]interface{}{
+		{int64(1), []byte("a,\"b\""), []byte{0xff, 0x00}, at, nil},
+		{[]byte("2"), "tab\there", nil, []byte("2024-01-02 03:04:05"), "x"},
+	}
+	tests := []struct {
+		format ExportFormat
+		opts   *ExportOptions
+		want   string
+	}{
+		{ExportCSV, &ExportOptions{TimeFormat: "2006-01-02"},
+			"id,name,data,created_at,note\n1,\"a,\"\"b\"\"\",/wA=,2024-01-02,\n2,tab\there,,2024-01-02,x\n"},
+		{ExportTSV, &ExportOptions{BinaryEncoding: BinaryHex, TimeFormat: "2006-01-02", Headers: map[string]string{"note": "remark"}},
+			"id\tname\tdata\tcreated_at\tremark\n1\ta,\"b\"\tff00\t2024-01-02\t\\N\n2\ttab\\there\t\\N\t2024-01-02\tx\n"},
+		{ExportJSONL, &ExportOptions{TimeFormat: "2006-01-02"},
+			`{"id":1,"name":"a,\"b\"","data":"/wA=","created_at":"2024-01-02","note":null}` + "\n" +
+				`{"id":2,"name":"tab\there","data":null,"created_at":"2024-01-02","note":"x"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			e, err := newExporter(&buf, tt.format, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.beginColumns(names, kinds); err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := e.row(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := e.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExporterTimeText(t *testing.T) {
+	e, err := newExporter(&bytes.Buffer{}, ExportCSV, &ExportOptions{TimeFormat: "2006/01/02 15:04:05.000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ in, want string }{
+		{"2024-01-02 03:04:05.123", "2024/01/02 03:04:05.123"},
+		{"2024-01-02", "2024/01/02 00:00:00.000"},
+		{"0000-00-00 00:00:00", "0000-00-00 00:00:00"},
+	}
+	for _, tt := range tests {
+		if got, _ := e.text([]byte(tt.in), kindTime); got != tt.want {
+			t.Errorf("text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewExporterInvalid(t *testing.T) {
+	if _, err := newExporter(&bytes.Buffer{}, "xml", &ExportOptions{}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if _, err := newExporter(&bytes.Buffer{}, ExportCSV, &ExportOptions{BinaryEncoding: "base32"}); err == nil {
+		t.Error("expected error for unsupported binary encoding")
+	}
+}
+
+func TestExportInvalidColumn(t *testing.T) {
+	c := newTestClient(t)
+	_, err := c.Table("orders").Export(context.Background(), &bytes.Buffer{}, ExportCSV, &ExportOptions{Columns: []string{"id; DROP"}})
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "export" || e.Table != "orders" || !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("error = %#v, want *Error wrapping ErrInvalidIdentifier", err)
+	}
+}
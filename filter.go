@@ -0,0 +1,243 @@
+package grds
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 过滤条件操作符
+const (
+	OpEq      = "eq"      // =
+	OpNe      = "ne"      // !=
+	OpGt      = "gt"      // >
+	OpGte     = "gte"     // >=
+	OpLt      = "lt"      // <
+	OpLte     = "lte"     // <=
+	OpLike    = "like"    // LIKE
+	OpIn      = "in"      // IN
+	OpNotIn   = "notin"   // NOT IN
+	OpBetween = "between" // BETWEEN，值为两个元素的切片或数组
+	OpNull    = "null"    // bool 值，true 为 IS NULL，false 为 IS NOT NULL
+)
+
+// filterTag 解析后的 grds 结构体标签
+type filterTag struct {
+	skip   bool
+	or     bool
+	column string
+	op     string
+}
+
+// parseFilterTag 解析 grds:"column=age;op=gte" 形式的标签
+func parseFilterTag(field reflect.StructField) filterTag {
+	tag := filterTag{op: OpEq}
+	value, ok := field.Tag.Lookup("grds")
+	if !ok {
+		return tag
+	}
+	if value == "-" {
+		tag.skip = true
+		return tag
+	}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		switch strings.ToLower(kv[0]) {
+		case "or":
+			tag.or = true
+		case "column":
+			if len(kv) == 2 {
+				tag.column = strings.TrimSpace(kv[1])
+			}
+		case "op":
+			if len(kv) == 2 {
+				tag.op = strings.ToLower(strings.TrimSpace(kv[1]))
+			}
+		}
+	}
+	return tag
+}
+
+// WhereStruct 根据结构体标签生成查询条件
+//
+// 字段标签格式为 grds:"column=age;op=gte"，column 默认取 gorm 的 column 标签或字段名的蛇形命名，
+// op 默认为 eq，可选 ne、gt、gte、lt、lte、like、in、notin、between、null。
+// 零值、nil 指针和空切片会被跳过，需要按零值过滤时使用指针字段。
+// op=null 的 bool 字段例外：false 生成 IS NOT NULL，需要可选时使用 *bool。
+// 嵌套结构体字段的条件作为一组加括号；标记 grds:"or" 的嵌套结构体组内条件以 OR 连接；
+// grds:"-" 忽略字段。
+func (qb *QueryBuilder) WhereStruct(filter interface{}) *QueryBuilder {
+	rv := reflect.ValueOf(filter)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return qb
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		_ = qb.db.AddError(fmt.Errorf("where struct: filter must be a struct, got %T", filter))
+		return qb
+	}
+
+	exprs, err := qb.structConditions(rv)
+	if err != nil {
+		_ = qb.db.AddError(err)
+		return qb
+	}
+	if len(exprs) > 0 {
+		qb.db = qb.db.Where(clause.And(exprs...))
+	}
+	return qb
+}
+
+// structConditions 收集结构体中各字段生成的条件
+func (qb *QueryBuilder) structConditions(rv reflect.Value) ([]clause.Expression, error) {
+	rt := rv.Type()
+	exprs := make([]clause.Expression, 0, rt.NumField())
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := parseFilterTag(field)
+		if tag.skip {
+			continue
+		}
+
+		value := rv.Field(i)
+		if !value.CanInterface() {
+			continue
+		}
+		// op=null 的 bool 为 false 时表示 IS NOT NULL，不能当作零值跳过
+		if isNilOrZero(value) && !(tag.op == OpNull && value.Kind() == reflect.Bool) {
+			continue
+		}
+		value = reflect.Indirect(value)
+
+		// 嵌套结构体：匿名字段展开，其余作为一组条件
+		if value.Kind() == reflect.Struct && tag.column == "" && !isScalarStruct(value) {
+			nested, err := qb.structConditions(value)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case len(nested) == 0:
+			case field.Anonymous && !tag.or:
+				exprs = append(exprs, nested...)
+			case len(nested) == 1:
+				exprs = append(exprs, nested[0])
+			case tag.or:
+				exprs = append(exprs, clause.Or(nested...))
+			default:
+				exprs = append(exprs, clause.And(nested...))
+			}
+			continue
+		}
+
+		column := tag.column
+		if column == "" {
+			column = schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]
+		}
+		if column == "" {
+			column = qb.db.NamingStrategy.ColumnName("", field.Name)
+		}
+
+		expr, err := qb.filterCondition(column, tag.op, value)
+		if err != nil {
+			return nil, fmt.Errorf("where struct: field %s: %w", field.Name, err)
+		}
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	return exprs, nil
+}
+
+// filterCondition 生成单个字段的条件，返回 nil 表示跳过
+func (qb *QueryBuilder) filterCondition(column, op string, value reflect.Value) (clause.Expression, error) {
+	col, err := qb.quoteColumn(column)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpLike:
+		return clause.Expr{SQL: col + " " + comparisonOperators[op] + " ?", Vars: []interface{}{value.Interface()}}, nil
+	case OpIn, OpNotIn:
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return nil, fmt.Errorf("op %s requires a slice, got %s", op, value.Type())
+		}
+		if value.Len() == 0 {
+			return nil, nil
+		}
+		return clause.Expr{SQL: col + " " + comparisonOperators[op] + " ?", Vars: []interface{}{value.Interface()}}, nil
+	case OpBetween:
+		if (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Len() != 2 {
+			return nil, fmt.Errorf("op between requires two values, got %s", value.Type())
+		}
+		low, high := value.Index(0), value.Index(1)
+		switch {
+		case isNilOrZero(low) && isNilOrZero(high):
+			return nil, nil
+		case isNilOrZero(low) && low.Kind() == reflect.Ptr:
+			return clause.Expr{SQL: col + " <= ?", Vars: []interface{}{high.Interface()}}, nil
+		case isNilOrZero(high) && high.Kind() == reflect.Ptr:
+			return clause.Expr{SQL: col + " >= ?", Vars: []interface{}{low.Interface()}}, nil
+		}
+		return clause.Expr{SQL: col + " BETWEEN ? AND ?", Vars: []interface{}{low.Interface(), high.Interface()}}, nil
+	case OpNull:
+		if value.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("op null requires a bool, got %s", value.Type())
+		}
+		if value.Bool() {
+			return clause.Expr{SQL: col + " IS NULL"}, nil
+		}
+		return clause.Expr{SQL: col + " IS NOT NULL"}, nil
+	}
+	return nil, fmt.Errorf("unsupported op %q", op)
+}
+
+// comparisonOperators 操作符对应的 SQL
+var comparisonOperators = map[string]string{
+	OpEq:    "=",
+	OpNe:    "!=",
+	OpGt:    ">",
+	OpGte:   ">=",
+	OpLt:    "<",
+	OpLte:   "<=",
+	OpLike:  "LIKE",
+	OpIn:    "IN",
+	OpNotIn: "NOT IN",
+}
+
+// isNilOrZero 值为 nil、零值或空切片时返回 true；指向零值的指针不算
+func isNilOrZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// isScalarStruct 判断结构体是否作为单个值使用（如 time.Time、sql.NullString）
+func isScalarStruct(v reflect.Value) bool {
+	if _, ok := v.Interface().(driver.Valuer); ok {
+		return true
+	}
+	if v.CanAddr() {
+		if _, ok := v.Addr().Interface().(interface{ Scan(interface{}) error }); ok {
+			return true
+		}
+	}
+	return v.Type().PkgPath() == "time"
+}
@@ -0,0 +1,143 @@
+package grds
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type userFilter struct {
+	Name      string    `grds:"op=like"`
+	Status    *int      `grds:"op=eq"`
+	Role      string    `grds:"op=ne"`
+	MinAge    int       `grds:"column=age;op=gte"`
+	MaxAge    int       `grds:"column=age;op=lte"`
+	Score     float64   `grds:"op=gt"`
+	Level     *int      `grds:"op=lt"`
+	IDs       []int64   `grds:"column=id;op=in"`
+	Excluded  []string  `grds:"column=name;op=notin"`
+	Created   []*int64  `grds:"column=created_at;op=between"`
+	Updated   [2]int64  `grds:"column=updated_at;op=between"`
+	Deleted   *bool     `grds:"column=deleted_at;op=null"`
+	Email     string    `gorm:"column:mail"`
+	Since     time.Time `grds:"column=created;op=gte"`
+	Internal  string    `grds:"-"`
+	Keyword   *keywordFilter
+	AnyOf     *orFilter `grds:"or"`
+	unexposed string
+}
+
+type keywordFilter struct {
+	Title string `grds:"op=like"`
+	Body  string `grds:"op=like"`
+}
+
+type orFilter struct {
+	Owner int64
+	Group int64
+}
+
+func TestWhereStructSQL(t *testing.T) {
+	c := newTestClient(t)
+	zero, one, five := 0, 1, 5
+	low, high := int64(10), int64(20)
+	yes, no := true, false
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   string
+		vars   []interface{}
+	}{
+		{"zero values skipped", userFilter{}, "SELECT * FROM `users`", nil},
+		{"nil filter", (*userFilter)(nil), "SELECT * FROM `users`", nil},
+		{"like", userFilter{Name: "%tom%"}, "SELECT * FROM `users` WHERE `name` LIKE ?", []interface{}{"%tom%"}},
+		{"pointer to zero", userFilter{Status: &zero}, "SELECT * FROM `users` WHERE `status` = ?", []interface{}{0}},
+		{"ne", userFilter{Role: "admin"}, "SELECT * FROM `users` WHERE `role` != ?", []interface{}{"admin"}},
+		{"gte and lte", userFilter{MinAge: 18, MaxAge: 30},
+			"SELECT * FROM `users` WHERE (`age` >= ? AND `age` <= ?)", []interface{}{18, 30}},
+		{"gt", userFilter{Score: 1.5}, "SELECT * FROM `users` WHERE `score` > ?", []interface{}{1.5}},
+		{"lt pointer", userFilter{Level: &five}, "SELECT * FROM `users` WHERE `level` < ?", []interface{}{5}},
+		{"in", userFilter{IDs: []int64{1, 2}}, "SELECT * FROM `users` WHERE `id` IN (?,?)", []interface{}{int64(1), int64(2)}},
+		{"empty in skipped", userFilter{IDs: []int64{}}, "SELECT * FROM `users`", nil},
+		{"not in", userFilter{Excluded: []string{"a"}}, "SELECT * FROM `users` WHERE `name` NOT IN (?)", []interface{}{"a"}},
+		{"between", userFilter{Created: []*int64{&low, &high}},
+			"SELECT * FROM `users` WHERE `created_at` BETWEEN ? AND ?", []interface{}{&low, &high}},
+		{"between open low", userFilter{Created: []*int64{nil, &high}},
+			"SELECT * FROM `users` WHERE `created_at` <= ?", []interface{}{&high}},
+		{"between open high", userFilter{Created: []*int64{&low, nil}},
+			"SELECT * FROM `users` WHERE `created_at` >= ?", []interface{}{&low}},
+		{"between array", userFilter{Updated: [2]int64{1, 2}},
+			"SELECT * FROM `users` WHERE `updated_at` BETWEEN ? AND ?", []interface{}{int64(1), int64(2)}},
+		{"between zero bound kept", userFilter{Updated: [2]int64{0, 2}},
+			"SELECT * FROM `users` WHERE `updated_at` BETWEEN ? AND ?", []interface{}{int64(0), int64(2)}},
+		{"is null", userFilter{Deleted: &yes}, "SELECT * FROM `users` WHERE `deleted_at` IS NULL", nil},
+		{"is not null", userFilter{Deleted: &no}, "SELECT * FROM `users` WHERE `deleted_at` IS NOT NULL", nil},
+		{"plain bool null", struct {
+			Deleted bool `grds:"column=deleted_at;op=null"`
+		}{true}, "SELECT * FROM `users` WHERE `deleted_at` IS NULL", nil},
+		{"plain bool not null", struct {
+			Deleted bool `grds:"column=deleted_at;op=null"`
+		}{false}, "SELECT * FROM `users` WHERE `deleted_at` IS NOT NULL", nil},
+		{"gorm column tag", userFilter{Email: "a@b.c"}, "SELECT * FROM `users` WHERE `mail` = ?", []interface{}{"a@b.c"}},
+		{"time value", userFilter{Since: since}, "SELECT * FROM `users` WHERE `created` >= ?", []interface{}{since}},
+		{"ignored field", userFilter{Internal: "x", unexposed: "y"}, "SELECT * FROM `users`", nil},
+		{"nested group", userFilter{Keyword: &keywordFilter{Title: "%a%", Body: "%b%"}, Status: &one},
+			"SELECT * FROM `users` WHERE (`status` = ? AND (`title` LIKE ? AND `body` LIKE ?))", []interface{}{1, "%a%", "%b%"}},
+		{"or group", &userFilter{AnyOf: &orFilter{Owner: 1, Group: 2}},
+			"SELECT * FROM `users` WHERE (`owner` = ? OR `group` = ?)", []interface{}{int64(1), int64(2)}},
+		{"single nested", userFilter{AnyOf: &orFilter{Owner: 1}},
+			"SELECT * FROM `users` WHERE `owner` = ?", []interface{}{int64(1)}},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		sql, vars := c.lastSQL(t, c.Table("users").WhereStruct(tt.filter).Find(&rows))
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestWhereStructInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name   string
+		filter interface{}
+		err    string
+	}{
+		{"not struct", 1, "filter must be a struct"},
+		{"unsupported op", struct {
+			Age int `grds:"op=regexp"`
+		}{1}, `unsupported op "regexp"`},
+		{"in requires slice", struct {
+			Age int `grds:"op=in"`
+		}{1}, "op in requires a slice"},
+		{"between requires two", struct {
+			Age []int `grds:"op=between"`
+		}{[]int{1}}, "op between requires two values"},
+		{"null requires bool", struct {
+			Age int `grds:"op=null"`
+		}{1}, "op null requires a bool"},
+		{"trailing separator", struct {
+			Age int `grds:"column=age;"`
+		}{1}, ""},
+		{"injected column", struct {
+			Age int `grds:"column=age) OR (1=1"`
+		}{1}, "invalid identifier"},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		err := c.Table("users").WhereStruct(tt.filter).Find(&rows)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
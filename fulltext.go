@@ -0,0 +1,115 @@
+package grds
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// MatchMode 全文检索模式
+type MatchMode string
+
+const (
+	MatchNaturalLanguage MatchMode = "IN NATURAL LANGUAGE MODE"
+	MatchBoolean         MatchMode = "IN BOOLEAN MODE"
+	MatchQueryExpansion  MatchMode = "WITH QUERY EXPANSION"
+)
+
+const fullTextKey = "grds:fulltext"
+
+// WhereMatch 全文检索条件：MATCH(columns) AGAINST (query mode)
+//
+// columns 必须与某个 FULLTEXT 索引的列完全一致。MatchBoolean 模式下 query 中的 + - * " 等是操作符，
+// 用户输入应先经过 EscapeMatchQuery。mode 为空时使用自然语言模式。
+//
+//	grds.Table("products").WhereMatch([]string{"title", "body"}, grds.EscapeMatchQuery(input), grds.MatchBoolean)
+func (qb *QueryBuilder) WhereMatch(columns []string, query string, mode MatchMode) *QueryBuilder {
+	expr, ok := qb.matchExpr(columns, query, mode)
+	if !ok {
+		return qb
+	}
+	qb.db = qb.db.Where(expr).Set(fullTextKey, expr)
+	return qb
+}
+
+// SelectRelevance 选出最近一次 WhereMatch 的相关度作为 alias 列，并按相关度降序排列
+//
+// 已通过 Select 选择列时保留这些列，否则选择 *。接收结果的结构体可以用只读字段：
+//
+//	Score float64 `gorm:"column:score;->"`
+func (qb *QueryBuilder) SelectRelevance(alias string) *QueryBuilder {
+	value, ok := qb.db.Get(fullTextKey)
+	if !ok {
+		_ = qb.db.AddError(fmt.Errorf("select relevance: WhereMatch must be called first"))
+		return qb
+	}
+	quoted, err := QuoteIdentifier(alias)
+	if err != nil || strings.Contains(alias, ".") {
+		_ = qb.db.AddError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, alias))
+		return qb
+	}
+
+	expr := value.(clause.Expr)
+	columns := "*"
+	if selects := qb.db.Statement.Selects; len(selects) > 0 {
+		columns = strings.Join(selects, ", ")
+	}
+	qb.db = qb.db.Select(columns+", "+expr.SQL+" AS "+quoted, expr.Vars...).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: alias}, Desc: true})
+	return qb
+}
+
+// matchExpr 校验列名并生成 MATCH ... AGAINST 表达式
+func (qb *QueryBuilder) matchExpr(columns []string, query string, mode MatchMode) (clause.Expr, bool) {
+	if len(columns) == 0 {
+		_ = qb.db.AddError(fmt.Errorf("where match: at least one column is required"))
+		return clause.Expr{}, false
+	}
+	switch mode {
+	case "":
+		mode = MatchNaturalLanguage
+	case MatchNaturalLanguage, MatchBoolean, MatchQueryExpansion:
+	default:
+		_ = qb.db.AddError(fmt.Errorf("where match: unsupported mode %q", mode))
+		return clause.Expr{}, false
+	}
+
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		col, ok := qb.column(column)
+		if !ok {
+			return clause.Expr{}, false
+		}
+		quoted = append(quoted, col)
+	}
+	sql := "MATCH(" + strings.Join(quoted, ", ") + ") AGAINST (? " + string(mode) + ")"
+	return clause.Expr{SQL: sql, Vars: []interface{}{query}}, true
+}
+
+// booleanOperators 布尔模式中有特殊含义的字符
+var booleanOperators = strings.NewReplacer(
+	"+", " ", "-", " ", "<", " ", ">", " ", "(", " ", ")", " ",
+	"~", " ", "*", " ", "\"", " ", "@", " ",
+)
+
+// EscapeMatchQuery 去掉用户输入中的布尔模式操作符，只保留普通检索词
+//
+//	EscapeMatchQuery(`"iphone" -case +(pro)`) == "iphone case pro"
+func EscapeMatchQuery(input string) string {
+	return strings.Join(strings.Fields(booleanOperators.Replace(input)), " ")
+}
+
+// MatchAllTerms 把用户输入转换为要求所有词都出现的布尔模式查询，最后一个词按前缀匹配
+//
+//	MatchAllTerms("red sho") == "+red +sho*"
+func MatchAllTerms(input string) string {
+	terms := strings.Fields(EscapeMatchQuery(input))
+	for i, term := range terms {
+		terms[i] = "+" + term
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,61 @@
+package grds
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEscapeMatchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		all   string
+	}{
+		{"", "", ""},
+		{`"iphone" -case +(pro)`, "iphone case pro", "+iphone +case +pro*"},
+		{"red sho", "red sho", "+red +sho*"},
+		{"  a~b  <c>d@2 e* ", "a b c d 2 e", "+a +b +c +d +2 +e*"},
+		{"+-*", "", ""},
+		{"café 手机", "café 手机", "+café +手机*"},
+	}
+	for _, tt := range tests {
+		if got := EscapeMatchQuery(tt.input); got != tt.want {
+			t.Errorf("EscapeMatchQuery(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if got := MatchAllTerms(tt.input); got != tt.all {
+			t.Errorf("MatchAllTerms(%q) = %q, want %q", tt.input, got, tt.all)
+		}
+	}
+}
+
+func TestWhereMatchSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+	}{
+		{"natural", c.Table("products").WhereMatch([]string{"title", "body"}, "phone", ""),
+			"SELECT * FROM `products` WHERE MATCH(`title`, `body`) AGAINST (? IN NATURAL LANGUAGE MODE)"},
+		{"relevance", c.Table("products").Select("id").WhereMatch([]string{"title"}, "+phone", MatchBoolean).SelectRelevance("score"),
+			"SELECT id, MATCH(`title`) AGAINST (? IN BOOLEAN MODE) AS `score` FROM `products` WHERE MATCH(`title`) AGAINST (? IN BOOLEAN MODE) ORDER BY `score` DESC"},
+	}
+	for _, tt := range tests {
+		if got, _ := toSQL(t, tt.qb, SQLFind); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, qb := range []*QueryBuilder{
+		c.Table("products").WhereMatch(nil, "phone", ""),
+		c.Table("products").WhereMatch([]string{"title"}, "phone", "IN SOME MODE"),
+		c.Table("products").SelectRelevance("score"),
+	} {
+		if _, _, err := qb.ToSQL(SQLFind); err == nil {
+			t.Error("expected error")
+		}
+	}
+	if _, _, err := c.Table("products").WhereMatch([]string{"title;"}, "x", "").ToSQL(SQLFind); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("invalid column error = %v", err)
+	}
+}
@@ -112,6 +112,17 @@ func getDefaultTypeMapping() map[string]string {
 		// JSON
 		"json": "string",
 
+		// 空间类型，其余几何类型为 MySQL 内部格式（4 字节 SRID + WKB）
+		"point":              "grds.Point",
+		"polygon":            "grds.Polygon",
+		"geometry":           "[]byte",
+		"linestring":         "[]byte",
+		"multipoint":         "[]byte",
+		"multilinestring":    "[]byte",
+		"multipolygon":       "[]byte",
+		"geometrycollection": "[]byte",
+		"geomcollection":     "[]byte",
+
 		// 其他
 		"enum": "string",
 		"set":  "string",
@@ -167,7 +178,7 @@ func (gc *GeneratorConfig) Generate() error {
 
 // generateSingleFile 将所有表生成到一个文件
 func (gc *GeneratorConfig) generateSingleFile(tables []string) error {
-	var modelsCodes []string
+	var modelsCodes, fieldTypes []string
 	for _, tableName := range tables {
 		columns, err := gc.GetTableColumns(tableName)
 		if err != nil {
@@ -187,11 +198,12 @@ func (gc *GeneratorConfig) generateSingleFile(tables []string) error {
 		}
 
 		modelsCodes = append(modelsCodes, code)
+		fieldTypes = append(fieldTypes, gc.fieldTypes(columns)...)
 	}
 
 	// 写入文件
 	outputPath := fmt.Sprintf("%s/%s", gc.OutDir, gc.OutFileName)
-	content := gc.buildFileContent(modelsCodes)
+	content := gc.buildFileContent(modelsCodes, fieldTypes)
 
 	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
@@ -224,7 +236,7 @@ func (gc *GeneratorConfig) generateSeparateFiles(tables []string) error {
 		// 文件名格式: 表名_model.go
 		fileName := fmt.Sprintf("%s_model.go", tableName)
 		outputPath := fmt.Sprintf("%s/%s", gc.OutDir, fileName)
-		content := gc.buildFileContent([]string{code})
+		content := gc.buildFileContent([]string{code}, gc.fieldTypes(columns))
 
 		if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("failed to write file %s: %w", fileName, err)
@@ -395,8 +407,26 @@ func toCamelCaseLower(s string) string {
 	return result
 }
 
-// buildFileContent 构建文件内容
-func (gc *GeneratorConfig) buildFileContent(modelsCodes []string) string {
+// fieldTypes 表字段对应的 Go 类型
+func (gc *GeneratorConfig) fieldTypes(columns []ColumnInfo) []string {
+	types := make([]string, len(columns))
+	for i, col := range columns {
+		types[i] = gc.mapDBTypeToGoType(col.Type)
+	}
+	return types
+}
+
+// typePackage 类型引用的包名，如 "*time.Time" 返回 "time"，内置类型返回空
+func typePackage(goType string) string {
+	name := strings.TrimLeft(goType, "*[]")
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// buildFileContent 构建文件内容，按字段类型确定需要导入的包（注释中出现的包名不影响导入）
+func (gc *GeneratorConfig) buildFileContent(modelsCodes []string, fieldTypes []string) string {
 	var buf bytes.Buffer
 
 	buf.WriteString(fmt.Sprintf("package %s\n\n", gc.PackageName))
@@ -404,17 +434,18 @@ func (gc *GeneratorConfig) buildFileContent(modelsCodes []string) string {
 	// 检查是否需要导入包
 	needTime := false
 	needJSON := gc.GenerateToJSON
-	for _, code := range modelsCodes {
-		if strings.Contains(code, "time.Time") {
+	needGrds := false
+	for _, goType := range fieldTypes {
+		switch typePackage(goType) {
+		case "time":
 			needTime = true
-		}
-		if strings.Contains(code, "json.Marshal") {
-			needJSON = true
+		case "grds":
+			needGrds = true
 		}
 	}
 
 	// 导入
-	if needTime || needJSON {
+	if needTime || needJSON || needGrds {
 		buf.WriteString("import (\n")
 		if needJSON {
 			buf.WriteString("\t\"encoding/json\"\n")
@@ -422,6 +453,12 @@ func (gc *GeneratorConfig) buildFileContent(modelsCodes []string) string {
 		if needTime {
 			buf.WriteString("\t\"time\"\n")
 		}
+		if needGrds {
+			if needTime || needJSON {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("\t\"github.com/nicexiaonie/grds\"\n")
+		}
 		buf.WriteString(")\n\n")
 	}
 
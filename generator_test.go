@@ -0,0 +1,35 @@
+package grds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildFileContentImports(t *testing.T) {
+	gc := &GeneratorConfig{PackageName: "model"}
+	// 注释中出现的包名不能导致导入未使用的包
+	code := "// Place 使用 grds.Point 存储 time.Time 之外的位置\ntype Place struct {\n\tID int64\n}\n"
+	tests := []struct {
+		name   string
+		types  []string
+		want   []string
+		absent []string
+	}{
+		{"builtin only", []string{"int64", "[]byte"}, nil, []string{"import", `"time"`, `"github.com/nicexiaonie/grds"`}},
+		{"time pointer", []string{"*time.Time"}, []string{`"time"`}, []string{`"github.com/nicexiaonie/grds"`}},
+		{"spatial", []string{"grds.Point", "time.Time"}, []string{`"time"`, `"github.com/nicexiaonie/grds"`}, nil},
+	}
+	for _, tt := range tests {
+		content := gc.buildFileContent([]string{code}, tt.types)
+		for _, s := range tt.want {
+			if !strings.Contains(content, s) {
+				t.Errorf("%s: missing %s in\n%s", tt.name, s, content)
+			}
+		}
+		for _, s := range tt.absent {
+			if strings.Contains(content, s) {
+				t.Errorf("%s: unexpected %s in\n%s", tt.name, s, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package grds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nicexiaonie/grds/internal/dbtest"
+	"gorm.io/gorm"
+)
+
+// testClient 不连接数据库的客户端，rec 记录执行过的语句
+type testClient struct {
+	*Client
+	rec *dbtest.Recorder
+}
+
+// newTestClient 创建 DryRun 模式的客户端，只生成 SQL 不执行
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	return newTestClientWithConfig(t, NewDefaultConfig())
+}
+
+// newTestClientWithConfig 使用指定配置创建 DryRun 模式的客户端
+func newTestClientWithConfig(t *testing.T, config *Config) *testClient {
+	t.Helper()
+	db, rec := dbtest.Open(t)
+	client, err := NewClientFromDB(db, config)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return &testClient{Client: client, rec: rec}
+}
+
+// newMockClient 创建由 sqlmock 驱动的客户端，用于需要返回结果的测试
+func newMockClient(t *testing.T, config *Config) (*Client, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.Mock(t)
+	client, err := NewClientFromDB(db, config)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return client, mock
+}
+
+// lastSQL 返回最近一条语句的 SQL 和参数
+//
+// err 为生成该语句的调用结果；DryRun 下读取结果集返回的 ErrDryRunModeUnsupported 视为成功。
+func (c *testClient) lastSQL(t *testing.T, err error) (string, []interface{}) {
+	t.Helper()
+	if err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmt, ok := c.rec.Last()
+	if !ok {
+		t.Fatal("no statement recorded")
+	}
+	return stmt.SQL, stmt.Vars
+}
+
+// toSQL 生成 SQL，失败时终止测试
+func toSQL(t *testing.T, qb *QueryBuilder, op SQLOp, values ...interface{}) (string, []interface{}) {
+	t.Helper()
+	sql, vars, err := qb.ToSQL(op, values...)
+	if err != nil {
+		t.Fatalf("to sql: %v", err)
+	}
+	return sql, vars
+}
@@ -0,0 +1,252 @@
+package grds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IndexHintType 索引提示类型
+type IndexHintType string
+
+const (
+	UseIndexHint    IndexHintType = "USE INDEX"
+	ForceIndexHint  IndexHintType = "FORCE INDEX"
+	IgnoreIndexHint IndexHintType = "IGNORE INDEX"
+)
+
+// IndexScope 索引提示的作用范围，为空时作用于整个查询
+type IndexScope string
+
+const (
+	IndexForJoin    IndexScope = "JOIN"
+	IndexForOrderBy IndexScope = "ORDER BY"
+	IndexForGroupBy IndexScope = "GROUP BY"
+)
+
+// IndexHint 索引提示，渲染在主表名（含别名）之后、JOIN 之前；也用于 UPDATE 的表名之后
+//
+// 可以直接通过 Clauses 使用：qb.Clauses(grds.IndexHint{Type: grds.UseIndexHint, Indexes: []string{"idx_a"}})
+type IndexHint struct {
+	Type    IndexHintType
+	Scope   IndexScope
+	Indexes []string
+}
+
+// Build 生成 USE INDEX FOR ORDER BY (`idx_a`, `idx_b`)
+func (h IndexHint) Build(builder clause.Builder) {
+	builder.WriteString(string(h.Type))
+	if h.Scope != "" {
+		builder.WriteString(" FOR ")
+		builder.WriteString(string(h.Scope))
+	}
+	builder.WriteString(" (")
+	for i, index := range h.Indexes {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteQuoted(index)
+	}
+	builder.WriteByte(')')
+}
+
+// ModifyStatement 把提示加到 FROM 和 UPDATE 子句之后
+func (h IndexHint) ModifyStatement(stmt *gorm.Statement) {
+	for _, name := range []string{"FROM", "UPDATE"} {
+		c := stmt.Clauses[name]
+		c.Name = name
+		hints, _ := c.AfterExpression.(indexHints)
+		c.AfterExpression = append(hints, h)
+		if name == "FROM" {
+			if c.Expression == nil {
+				c.Expression = clause.From{}
+			}
+			c.Builder = buildFromWithIndexHints
+		}
+		stmt.Clauses[name] = c
+	}
+}
+
+// indexHints 同一个表上的多个索引提示
+type indexHints []IndexHint
+
+// Build 以空格分隔
+func (hints indexHints) Build(builder clause.Builder) {
+	for i, h := range hints {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		h.Build(builder)
+	}
+}
+
+// buildFromWithIndexHints 生成 FROM 子句，把索引提示放在主表和 JOIN 之间
+//
+// 单表 DELETE 不支持索引提示，删除语句中忽略。
+func buildFromWithIndexHints(c clause.Clause, builder clause.Builder) {
+	if c.BeforeExpression != nil {
+		c.BeforeExpression.Build(builder)
+		builder.WriteByte(' ')
+	}
+	builder.WriteString(c.Name)
+	builder.WriteByte(' ')
+
+	from, ok := c.Expression.(clause.From)
+	if !ok {
+		c.Expression.Build(builder)
+		return
+	}
+	joins := from.Joins
+	from.Joins = nil
+	from.Build(builder)
+	if stmt, ok := builder.(*gorm.Statement); !ok || !isDeleteStatement(stmt) {
+		builder.WriteByte(' ')
+		c.AfterExpression.Build(builder)
+	}
+	for _, join := range joins {
+		builder.WriteByte(' ')
+		join.Build(builder)
+	}
+}
+
+// isDeleteStatement 是否为 DELETE 语句
+func isDeleteStatement(stmt *gorm.Statement) bool {
+	for _, name := range stmt.BuildClauses {
+		if name != commentClause {
+			return name == "DELETE"
+		}
+	}
+	return false
+}
+
+// UseIndex 建议使用索引：USE INDEX (...)
+func (qb *QueryBuilder) UseIndex(indexes ...string) *QueryBuilder {
+	return qb.indexHint(UseIndexHint, "", indexes)
+}
+
+// UseIndexFor 建议在指定范围使用索引：USE INDEX FOR JOIN|ORDER BY|GROUP BY (...)
+func (qb *QueryBuilder) UseIndexFor(scope IndexScope, indexes ...string) *QueryBuilder {
+	return qb.indexHint(UseIndexHint, scope, indexes)
+}
+
+// ForceIndex 强制使用索引：FORCE INDEX (...)
+func (qb *QueryBuilder) ForceIndex(indexes ...string) *QueryBuilder {
+	return qb.indexHint(ForceIndexHint, "", indexes)
+}
+
+// ForceIndexFor 强制在指定范围使用索引
+func (qb *QueryBuilder) ForceIndexFor(scope IndexScope, indexes ...string) *QueryBuilder {
+	return qb.indexHint(ForceIndexHint, scope, indexes)
+}
+
+// IgnoreIndex 忽略索引：IGNORE INDEX (...)
+func (qb *QueryBuilder) IgnoreIndex(indexes ...string) *QueryBuilder {
+	return qb.indexHint(IgnoreIndexHint, "", indexes)
+}
+
+// IgnoreIndexFor 在指定范围忽略索引
+func (qb *QueryBuilder) IgnoreIndexFor(scope IndexScope, indexes ...string) *QueryBuilder {
+	return qb.indexHint(IgnoreIndexHint, scope, indexes)
+}
+
+// indexHint 校验索引名后添加索引提示
+func (qb *QueryBuilder) indexHint(typ IndexHintType, scope IndexScope, indexes []string) *QueryBuilder {
+	if len(indexes) == 0 && typ != UseIndexHint {
+		_ = qb.db.AddError(fmt.Errorf("%s requires at least one index", typ))
+		return qb
+	}
+	for _, index := range indexes {
+		if !identifierPattern.MatchString(index) || len(index) > maxIdentifierLength {
+			_ = qb.db.AddError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, index))
+			return qb
+		}
+	}
+	switch scope {
+	case "", IndexForJoin, IndexForOrderBy, IndexForGroupBy:
+	default:
+		_ = qb.db.AddError(fmt.Errorf("invalid index hint scope: %q", scope))
+		return qb
+	}
+	return qb.Clauses(IndexHint{Type: typ, Scope: scope, Indexes: indexes})
+}
+
+// ==================== 优化器提示 ====================
+
+// OptimizerHint 优化器提示，渲染为紧跟在 SELECT/UPDATE/DELETE 之后的 /*+ ... */ 注释
+type OptimizerHint struct {
+	Hints []string
+}
+
+// Build 生成 /*+ HINT1 HINT2 */
+func (h OptimizerHint) Build(builder clause.Builder) {
+	builder.WriteString("/*+ ")
+	builder.WriteString(strings.Join(h.Hints, " "))
+	builder.WriteString(" */")
+}
+
+// ModifyStatement 把提示合并到 SELECT、UPDATE、DELETE 关键字之后
+func (h OptimizerHint) ModifyStatement(stmt *gorm.Statement) {
+	for _, name := range []string{"SELECT", "UPDATE", "DELETE"} {
+		c := stmt.Clauses[name]
+		merged := h
+		if existing, ok := c.AfterNameExpression.(OptimizerHint); ok {
+			merged = OptimizerHint{Hints: append(append([]string{}, existing.Hints...), h.Hints...)}
+		}
+		c.AfterNameExpression = merged
+		if name == "DELETE" {
+			// clause.Delete 自己输出 DELETE 关键字，子句名为空
+			c.Builder = buildDeleteWithOptimizerHint
+		} else {
+			c.Name = name
+		}
+		stmt.Clauses[name] = c
+	}
+}
+
+// buildDeleteWithOptimizerHint 生成 DELETE /*+ ... */
+func buildDeleteWithOptimizerHint(c clause.Clause, builder clause.Builder) {
+	if c.Expression == nil {
+		return
+	}
+	c.Expression.Build(builder)
+	builder.WriteByte(' ')
+	c.AfterNameExpression.Build(builder)
+}
+
+// OptimizerHints 添加优化器提示，例如 "BKA(t1)"、"NO_RANGE_OPTIMIZATION(t1 PRIMARY)"
+func (qb *QueryBuilder) OptimizerHints(hints ...string) *QueryBuilder {
+	for _, hint := range hints {
+		if strings.Contains(hint, "*/") || strings.TrimSpace(hint) == "" {
+			_ = qb.db.AddError(fmt.Errorf("invalid optimizer hint: %q", hint))
+			return qb
+		}
+	}
+	return qb.Clauses(OptimizerHint{Hints: hints})
+}
+
+// MaxExecutionTime 限制 SELECT 的执行时间：/*+ MAX_EXECUTION_TIME(ms) */
+func (qb *QueryBuilder) MaxExecutionTime(d time.Duration) *QueryBuilder {
+	ms := d.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	return qb.OptimizerHints(fmt.Sprintf("MAX_EXECUTION_TIME(%d)", ms))
+}
+
+// SetVar 仅对当前语句设置会话变量：/*+ SET_VAR(name=value) */
+//
+//	qb.SetVar("sort_buffer_size", "16M")
+func (qb *QueryBuilder) SetVar(name, value string) *QueryBuilder {
+	if !identifierPattern.MatchString(name) {
+		_ = qb.db.AddError(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+		return qb
+	}
+	if strings.ContainsAny(value, "()*/ \t\n") || value == "" {
+		_ = qb.db.AddError(fmt.Errorf("invalid SET_VAR value: %q", value))
+		return qb
+	}
+	return qb.OptimizerHints("SET_VAR(" + name + "=" + value + ")")
+}
@@ -0,0 +1,68 @@
+package grds
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHintsSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name   string
+		qb     *QueryBuilder
+		op     SQLOp
+		values []interface{}
+		want   string
+	}{
+		{"use index", c.Table("orders").UseIndex("idx_status").WhereEq("status", 1), SQLFind, nil,
+			"SELECT * FROM `orders` USE INDEX (`idx_status`) WHERE `status` = ?"},
+		{"empty use index", c.Table("orders").UseIndex(), SQLFind, nil,
+			"SELECT * FROM `orders` USE INDEX ()"},
+		{"multiple hints before join", c.Table("orders o").ForceIndexFor(IndexForOrderBy, "idx_a", "idx_b").IgnoreIndex("idx_c").
+			LeftJoin("users u", "u.id = o.user_id"), SQLFind, nil,
+			"SELECT * FROM orders o FORCE INDEX FOR ORDER BY (`idx_a`, `idx_b`) IGNORE INDEX (`idx_c`) LEFT JOIN `users` `u` ON u.id = o.user_id"},
+		{"count", c.Table("orders").UseIndexFor(IndexForJoin, "idx_a"), SQLCount, nil,
+			"SELECT count(*) FROM `orders` USE INDEX FOR JOIN (`idx_a`)"},
+		{"update", c.Table("orders").ForceIndex("idx_status").WhereEq("id", 1), SQLUpdate, []interface{}{"status", 2},
+			"UPDATE `orders` FORCE INDEX (`idx_status`) SET `status`=? WHERE `id` = ?"},
+		{"delete ignores index hint", c.Table("orders").UseIndex("idx_status").WhereEq("id", 1), SQLDelete, nil,
+			"DELETE FROM `orders` WHERE `id` = ?"},
+		{"optimizer hints merged", c.Table("orders").MaxExecutionTime(1500*time.Millisecond).SetVar("sort_buffer_size", "16M"), SQLFind, nil,
+			"SELECT /*+ MAX_EXECUTION_TIME(1500) SET_VAR(sort_buffer_size=16M) */ * FROM `orders`"},
+		{"optimizer hint update", c.Table("orders").OptimizerHints("NO_INDEX_MERGE(orders)").WhereEq("id", 1), SQLUpdate, []interface{}{"status", 2},
+			"UPDATE /*+ NO_INDEX_MERGE(orders) */ `orders` SET `status`=? WHERE `id` = ?"},
+		{"optimizer hint delete", c.Table("orders").OptimizerHints("BKA(orders)").WhereEq("id", 1), SQLDelete, nil,
+			"DELETE /*+ BKA(orders) */ FROM `orders` WHERE `id` = ?"},
+		{"minimum execution time", c.Table("orders").MaxExecutionTime(0), SQLFind, nil,
+			"SELECT /*+ MAX_EXECUTION_TIME(1) */ * FROM `orders`"},
+	}
+	for _, tt := range tests {
+		if got, _ := toSQL(t, tt.qb, tt.op, tt.values...); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHintsInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		err  error
+	}{
+		{"index name", c.Table("orders").UseIndex("idx`a"), ErrInvalidIdentifier},
+		{"force without index", c.Table("orders").ForceIndex(), nil},
+		{"scope", c.Table("orders").UseIndexFor("WHERE", "idx_a"), nil},
+		{"comment end", c.Table("orders").OptimizerHints("BKA(t) */ DROP"), nil},
+		{"blank hint", c.Table("orders").OptimizerHints(" "), nil},
+		{"set var name", c.Table("orders").SetVar("a-b", "1"), ErrInvalidIdentifier},
+		{"set var value", c.Table("orders").SetVar("sort_buffer_size", "1) */"), nil},
+	}
+	for _, tt := range tests {
+		_, _, err := tt.qb.ToSQL(SQLFind)
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
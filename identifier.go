@@ -0,0 +1,123 @@
+package grds
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidIdentifier 非法的表名或列名
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	// ErrColumnNotAllowed 列不在允许列表中
+	ErrColumnNotAllowed = errors.New("column not allowed")
+)
+
+// maxIdentifierLength MySQL 标识符最大长度
+const maxIdentifierLength = 64
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// ColumnAllowlist 列允许列表
+//
+// 模型实现该接口后，通过 Model() 创建的查询构建器只接受列表中的列，
+// 可以安全地把请求中的排序、过滤字段传给 WhereEq、OrderByDesc 等方法。
+type ColumnAllowlist interface {
+	AllowedColumns() []string
+}
+
+// ValidIdentifier 检查是否为合法的标识符（column 或 table.column）
+func ValidIdentifier(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > maxIdentifierLength || !identifierPattern.MatchString(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// QuoteIdentifier 校验并用反引号转义标识符，支持 column 和 table.column 形式
+func QuoteIdentifier(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if !ValidIdentifier(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	return "`" + strings.Replace(name, ".", "`.`", 1) + "`", nil
+}
+
+// quoteTableAlias 校验并转义表名，支持 "table alias" 和 "table AS alias" 形式
+func quoteTableAlias(name string) (string, error) {
+	fields := strings.Fields(name)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+		fields = []string{fields[0], fields[2]}
+	}
+	if len(fields) == 0 || len(fields) > 2 || strings.Contains(fields[len(fields)-1], ".") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+
+	quoted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		q, err := QuoteIdentifier(field)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+		quoted = append(quoted, q)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+// AllowColumns 设置当前查询允许使用的列，覆盖模型的 AllowedColumns
+//
+// 列名可以是 column 或 table.column；对 table.column 的检查同时接受仅列出 column 的情况。
+func (qb *QueryBuilder) AllowColumns(columns ...string) *QueryBuilder {
+	qb.allowedColumns = make(map[string]bool, len(columns))
+	for _, column := range columns {
+		qb.allowedColumns[strings.TrimSpace(column)] = true
+	}
+	return qb
+}
+
+// useModelAllowlist 模型实现 ColumnAllowlist 时启用其允许列表
+func (qb *QueryBuilder) useModelAllowlist(value interface{}) {
+	if allowlist, ok := value.(ColumnAllowlist); ok {
+		qb.AllowColumns(allowlist.AllowedColumns()...)
+	}
+}
+
+// columnAllowed 检查列是否在允许列表中（未设置列表时全部允许）
+func (qb *QueryBuilder) columnAllowed(column string) bool {
+	if qb.allowedColumns == nil || qb.allowedColumns[column] {
+		return true
+	}
+	if i := strings.LastIndexByte(column, '.'); i >= 0 {
+		return qb.allowedColumns[column[i+1:]]
+	}
+	return false
+}
+
+// quoteColumn 校验列名并返回转义结果
+func (qb *QueryBuilder) quoteColumn(column string) (string, error) {
+	quoted, err := QuoteIdentifier(column)
+	if err != nil {
+		return "", err
+	}
+	if !qb.columnAllowed(strings.TrimSpace(column)) {
+		return "", fmt.Errorf("%w: %q", ErrColumnNotAllowed, column)
+	}
+	return quoted, nil
+}
+
+// column 校验列名，失败时把错误记录到查询上，后续执行会返回该错误
+func (qb *QueryBuilder) column(column string) (string, bool) {
+	quoted, err := qb.quoteColumn(column)
+	if err != nil {
+		_ = qb.db.AddError(err)
+		return "", false
+	}
+	return quoted, true
+}
@@ -0,0 +1,134 @@
+package grds
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"id", true},
+		{"_created_at", true},
+		{"users.id", true},
+		{"col$1", true},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+		{"", false},
+		{"1col", false},
+		{"a.b.c", false},
+		{"users.", false},
+		{".id", false},
+		{"id desc", false},
+		{"`id`", false},
+		{"id;drop", false},
+		{"COUNT(*)", false},
+	}
+	for _, tt := range tests {
+		if got := ValidIdentifier(tt.name); got != tt.want {
+			t.Errorf("ValidIdentifier(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{"id", "`id`", false},
+		{" users.id ", "`users`.`id`", false},
+		{"a`b", "", true},
+		{"a.b.c", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := QuoteIdentifier(tt.name)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("QuoteIdentifier(%q) error = %v, want ErrInvalidIdentifier", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("QuoteIdentifier(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuoteTableAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{"users", "`users`", false},
+		{"users u", "`users` `u`", false},
+		{"users AS u", "`users` `u`", false},
+		{"db.users u", "`db`.`users` `u`", false},
+		{"users u.x", "", true},
+		{"users u v", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := quoteTableAlias(tt.name)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("quoteTableAlias(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestColumnAllowlist(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		column string
+		err    error
+	}{
+		{"name", nil},
+		{"users.name", nil},
+		{"email", ErrColumnNotAllowed},
+		{"name;", ErrInvalidIdentifier},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		err := c.Table("users").AllowColumns("name").WhereEq(tt.column, 1).Find(&rows)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("WhereEq(%q) error = %v, want %v", tt.column, err, tt.err)
+		}
+	}
+}
+
+func TestPluckColumn(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		column string
+		want   string
+		err    error
+	}{
+		{"name", "SELECT `name` FROM `users`", nil},
+		{" users.name ", "SELECT `users`.`name` FROM `users`", nil},
+		{"email", "", ErrColumnNotAllowed},
+		{"name FROM secrets --", "", ErrInvalidIdentifier},
+	}
+	for _, tt := range tests {
+		c.rec.Reset()
+		var names []string
+		err := c.Table("users").AllowColumns("name").Pluck(tt.column, &names)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Pluck(%q) error = %v, want %v", tt.column, err, tt.err)
+			}
+			if stmt, ok := c.rec.Last(); ok {
+				t.Errorf("Pluck(%q) executed %q", tt.column, stmt.SQL)
+			}
+			continue
+		}
+		if sql, _ := c.lastSQL(t, err); sql != tt.want {
+			t.Errorf("Pluck(%q) = %q, want %q", tt.column, sql, tt.want)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+package grds
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultImportBatchSize 默认每批插入的行数
+const DefaultImportBatchSize = 1000
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Columns       map[string]string // 源字段（表头或 JSON 键）到表列的映射；设置后未出现在映射中的字段被忽略
+	Fields        []string          // CSV/TSV 没有表头时各字段的名称
+	NoHeader      bool              // CSV/TSV 第一行不是表头
+	NullValue     string            // 视为 NULL 的字段值，默认 TSV 为 \N，CSV 不转换
+	EmptyAsNull   bool              // CSV/TSV 中空字段视为 NULL
+	BatchSize     int               // 每批插入的行数，默认 1000
+	Upsert        bool              // 唯一键冲突时更新（INSERT ... ON DUPLICATE KEY UPDATE）
+	UpdateColumns []string          // Upsert 时更新的列，默认为所有导入的列
+	MaxErrors     int               // 允许出错的行数，超过后停止导入；为 0 时遇到错误立即停止
+	LoadData      bool              // 使用 LOAD DATA LOCAL INFILE，仅支持 CSV/TSV，需要服务端开启 local_infile
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Rows     int64             // 读取的数据行数；LoadData 时为写入的行数
+	Inserted int64             // 影响的行数，Upsert 时更新的行计为 2
+	Errors   []*ImportRowError // 出错的行
+}
+
+// ImportRowError 一行数据的错误
+type ImportRowError struct {
+	Row int64 // 数据行序号，从 1 开始，不含表头
+	Err error
+}
+
+// Error 实现 error
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ErrTooManyImportErrors 出错的行数超过 MaxErrors
+var ErrTooManyImportErrors = errors.New("too many import errors")
+
+// Import 从 r 读取 CSV、TSV 或 JSON Lines 数据批量写入 table，格式与 Export 相同
+//
+// 数据逐批读取和插入，内存占用只与 BatchSize 有关。批量插入失败时逐行重试以定位出错的行，
+// 出错的行记录在 ImportResult.Errors 中。opts 为 nil 时使用默认选项。
+//
+//	f, _ := os.Open("users.csv")
+//	result, err := client.Import(ctx, "users", f, grds.ExportCSV, &grds.ImportOptions{
+//		Columns: map[string]string{"Name": "name", "E-mail": "email"},
+//		Upsert:  true,
+//	})
+func (c *Client) Import(ctx context.Context, table string, r io.Reader, format ExportFormat, opts *ImportOptions) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	if _, err := QuoteIdentifier(table); err != nil {
+		return nil, err
+	}
+	src, err := newImportSource(r, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.LoadData {
+		return c.loadData(ctx, table, src, opts)
+	}
+
+	imp := &importer{
+		db:     c.db.WithContext(ctx).Table(table),
+		opts:   opts,
+		result: &ImportResult{},
+	}
+	if imp.batchSize = opts.BatchSize; imp.batchSize <= 0 {
+		imp.batchSize = DefaultImportBatchSize
+	}
+	if err := imp.run(src); err != nil {
+		return imp.result, fmt.Errorf("import: %w", err)
+	}
+	return imp.result, nil
+}
+
+// importer 按批插入数据
+type importer struct {
+	db        *gorm.DB
+	opts      *ImportOptions
+	batchSize int
+	result    *ImportResult
+	batch     []map[string]interface{}
+	rowNums   []int64
+}
+
+// run 读取所有行并插入
+func (imp *importer) run(src *importSource) error {
+	for {
+		fields, values, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		imp.result.Rows++
+		if err == nil {
+			var row map[string]interface{}
+			if row, err = imp.mapRow(fields, values); err == nil {
+				imp.batch = append(imp.batch, row)
+				imp.rowNums = append(imp.rowNums, imp.result.Rows)
+			}
+		}
+		if err != nil {
+			var rowErr *importFormatError
+			if !errors.As(err, &rowErr) {
+				return err
+			}
+			if err := imp.fail(imp.result.Rows, rowErr.err); err != nil {
+				return err
+			}
+		}
+		if len(imp.batch) >= imp.batchSize {
+			if err := imp.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return imp.flush()
+}
+
+// mapRow 按列映射把一行字段转换为列值
+func (imp *importer) mapRow(fields []string, values []interface{}) (map[string]interface{}, error) {
+	row := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		column := field
+		if imp.opts.Columns != nil {
+			var ok bool
+			if column, ok = imp.opts.Columns[field]; !ok {
+				continue
+			}
+		}
+		if !ValidIdentifier(column) || strings.Contains(column, ".") {
+			return nil, &importFormatError{err: fmt.Errorf("%w: %q", ErrInvalidIdentifier, column)}
+		}
+		row[column] = values[i]
+	}
+	if len(row) == 0 {
+		return nil, &importFormatError{err: fmt.Errorf("no columns mapped")}
+	}
+	return row, nil
+}
+
+// flush 插入当前批次
+//
+// JSON Lines 各行的键可能不同，缺少的列不能写成 NULL（会覆盖列的默认值），连续的列相同的行一起插入。
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	batch, rowNums := imp.batch, imp.rowNums
+	imp.batch, imp.rowNums = imp.batch[:0:0], imp.rowNums[:0:0]
+
+	for start := 0; start < len(batch); {
+		end := start + 1
+		for end < len(batch) && sameColumns(batch[start], batch[end]) {
+			end++
+		}
+		if err := imp.insertRows(batch[start:end], rowNums[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// insertRows 插入列相同的若干行，失败时逐行重试
+func (imp *importer) insertRows(batch []map[string]interface{}, rowNums []int64) error {
+	tx := imp.insert(batch)
+	if tx.Error == nil {
+		imp.result.Inserted += tx.RowsAffected
+		return nil
+	}
+	if len(batch) == 1 {
+		return imp.fail(rowNums[0], tx.Error)
+	}
+	for i, row := range batch {
+		if err := imp.db.Statement.Context.Err(); err != nil {
+			return err
+		}
+		tx := imp.insert([]map[string]interface{}{row})
+		if tx.Error != nil {
+			if err := imp.fail(rowNums[i], tx.Error); err != nil {
+				return err
+			}
+			continue
+		}
+		imp.result.Inserted += tx.RowsAffected
+	}
+	return nil
+}
+
+// sameColumns 两行的列是否相同
+func sameColumns(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for column := range a {
+		if _, ok := b[column]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// insert 插入一批列相同的数据
+func (imp *importer) insert(rows []map[string]interface{}) *gorm.DB {
+	tx := imp.db.Session(&gorm.Session{})
+	if imp.opts.Upsert {
+		columns := imp.opts.UpdateColumns
+		if len(columns) == 0 {
+			for column := range rows[0] {
+				columns = append(columns, column)
+			}
+			sort.Strings(columns)
+		}
+		tx = tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns(columns)})
+	}
+	return tx.CreateInBatches(rows, len(rows))
+}
+
+// fail 记录出错的行，超过 MaxErrors 时返回错误
+func (imp *importer) fail(row int64, err error) error {
+	rowErr := &ImportRowError{Row: row, Err: err}
+	imp.result.Errors = append(imp.result.Errors, rowErr)
+	if imp.opts.MaxErrors == 0 {
+		return rowErr
+	}
+	if len(imp.result.Errors) > imp.opts.MaxErrors {
+		return fmt.Errorf("%w: %d rows failed, last %v", ErrTooManyImportErrors, len(imp.result.Errors), rowErr)
+	}
+	return nil
+}
+
+// ==================== 数据源 ====================
+
+// importFormatError 一行数据格式错误，可以跳过该行继续读取
+type importFormatError struct {
+	err error
+}
+
+// Error 实现 error
+func (e *importFormatError) Error() string {
+	return e.err.Error()
+}
+
+// importSource 按格式逐行读取数据
+type importSource struct {
+	format  ExportFormat
+	opts    *ImportOptions
+	null    string
+	reader  *bufio.Reader
+	csv     *csv.Reader
+	headers []string
+}
+
+// newImportSource 校验格式并读取表头
+func newImportSource(r io.Reader, format ExportFormat, opts *ImportOptions) (*importSource, error) {
+	src := &importSource{format: format, opts: opts, null: opts.NullValue, reader: bufio.NewReader(r)}
+	switch format {
+	case ExportCSV:
+		src.csv = csv.NewReader(src.reader)
+		src.csv.FieldsPerRecord = -1
+		src.csv.ReuseRecord = true
+	case ExportTSV:
+		if src.null == "" {
+			src.null = `\N`
+		}
+	case ExportJSONL:
+		if opts.LoadData {
+			return nil, fmt.Errorf("import: load data does not support format %q", format)
+		}
+		return src, nil
+	default:
+		return nil, fmt.Errorf("import: unsupported format %q", format)
+	}
+
+	if opts.NoHeader {
+		if len(opts.Fields) == 0 {
+			return nil, fmt.Errorf("import: fields are required when there is no header")
+		}
+		src.headers = opts.Fields
+		return src, nil
+	}
+	header, err := src.readHeader()
+	if err == io.EOF {
+		return nil, fmt.Errorf("import: missing header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("import: read header: %w", err)
+	}
+	src.headers = header
+	return src, nil
+}
+
+// readHeader 读取第一行作为表头
+//
+// LOAD DATA 需要把剩余数据原样交给服务端，所以只读取一行，不经过 csv.Reader 的缓冲。
+func (src *importSource) readHeader() ([]string, error) {
+	line, err := src.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	line = strings.TrimPrefix(line, "\ufeff")
+	if src.format == ExportTSV {
+		return splitTSV(line), nil
+	}
+	return csv.NewReader(strings.NewReader(line)).Read()
+}
+
+// next 读取下一行，返回字段名和值；格式错误返回 *importFormatError
+func (src *importSource) next() ([]string, []interface{}, error) {
+	switch src.format {
+	case ExportJSONL:
+		return src.nextJSON()
+	case ExportTSV:
+		line, err := src.reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil, nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		return src.record(splitTSV(strings.TrimRight(line, "\r\n")))
+	}
+
+	record, err := src.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, nil, &importFormatError{err: err}
+		}
+		return nil, nil, err
+	}
+	return src.record(record)
+}
+
+// record 把 CSV/TSV 的一行转换为列值
+func (src *importSource) record(record []string) ([]string, []interface{}, error) {
+	if len(record) != len(src.headers) {
+		return nil, nil, &importFormatError{err: fmt.Errorf("expected %d fields, got %d", len(src.headers), len(record))}
+	}
+	values := make([]interface{}, len(record))
+	for i, field := range record {
+		switch {
+		case src.null != "" && field == src.null:
+			values[i] = nil
+		case src.opts.EmptyAsNull && field == "":
+			values[i] = nil
+		case src.format == ExportTSV:
+			values[i] = unescapeTSV(field)
+		default:
+			values[i] = field
+		}
+	}
+	return src.headers, values, nil
+}
+
+// nextJSON 读取一行 JSON 对象，嵌套的对象和数组序列化为 JSON 文本
+func (src *importSource) nextJSON() ([]string, []interface{}, error) {
+	for {
+		line, err := src.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, nil, err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return nil, nil, &importFormatError{err: err}
+		}
+		fields := make([]string, 0, len(doc))
+		values := make([]interface{}, 0, len(doc))
+		for key, raw := range doc {
+			value, err := jsonImportValue(raw)
+			if err != nil {
+				return nil, nil, &importFormatError{err: err}
+			}
+			fields = append(fields, key)
+			values = append(values, value)
+		}
+		return fields, values, nil
+	}
+}
+
+// jsonImportValue 转换 JSON 值：数字保留原文，对象和数组保留 JSON 文本
+func jsonImportValue(raw json.RawMessage) (interface{}, error) {
+	switch raw[0] {
+	case 'n':
+		return nil, nil
+	case 't', 'f':
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	case '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+	// 数字交给 MySQL 转换，避免精度损失；对象和数组写入 JSON 列
+	return string(raw), nil
+}
+
+// splitTSV 按制表符分隔字段
+func splitTSV(line string) []string {
+	return strings.Split(line, "\t")
+}
+
+// tsvUnescaper 与 tsvEscaper 相反
+var tsvUnescaper = strings.NewReplacer(
+	`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r", `\0`, "\x00", `\Z`, "\x1a",
+)
+
+// unescapeTSV 还原 TSV 字段
+func unescapeTSV(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return tsvUnescaper.Replace(s)
+}
+
+// ==================== LOAD DATA ====================
+
+// loadDataSeq 用于生成唯一的 reader 名称
+var loadDataSeq uint64
+
+// loadData 通过 LOAD DATA LOCAL INFILE 导入剩余数据
+//
+// 所有字段先读入用户变量再赋值给列，未映射的字段被丢弃。Upsert 时使用 REPLACE，冲突的行会被删除后重新插入。
+// 这种方式不经过 GORM 的回调，完成后失效该表的查询缓存。
+func (c *Client) loadData(ctx context.Context, table string, src *importSource, opts *ImportOptions) (*ImportResult, error) {
+	var vars, sets []string
+	for i, field := range src.headers {
+		column := field
+		if opts.Columns != nil {
+			var ok bool
+			if column, ok = opts.Columns[field]; !ok {
+				vars = append(vars, "@grds_skip")
+				continue
+			}
+		}
+		quoted, err := QuoteIdentifier(column)
+		if err != nil || strings.Contains(column, ".") {
+			return nil, fmt.Errorf("import: %w: %q", ErrInvalidIdentifier, column)
+		}
+		v := "@grds_f" + strconv.Itoa(i)
+		vars = append(vars, v)
+		sets = append(sets, quoted+" = "+src.loadDataValue(v, i == len(src.headers)-1))
+	}
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("import: no columns mapped")
+	}
+
+	name := "grds_import_" + strconv.FormatUint(atomic.AddUint64(&loadDataSeq, 1), 10)
+	mysql.RegisterReaderHandler(name, func() io.Reader { return src.reader })
+	defer mysql.DeregisterReaderHandler(name)
+
+	quotedTable, err := QuoteIdentifier(table)
+	if err != nil {
+		return nil, err
+	}
+	var sql strings.Builder
+	sql.WriteString("LOAD DATA LOCAL INFILE 'Reader::" + name + "'")
+	if opts.Upsert {
+		sql.WriteString(" REPLACE")
+	}
+	sql.WriteString(" INTO TABLE " + quotedTable + " CHARACTER SET utf8mb4")
+	if src.format == ExportCSV {
+		sql.WriteString(` FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '"' ESCAPED BY ''`)
+	} else {
+		sql.WriteString(` FIELDS TERMINATED BY '\t' ESCAPED BY '\\'`)
+	}
+	sql.WriteString(` LINES TERMINATED BY '\n'`)
+	sql.WriteString(" (" + strings.Join(vars, ", ") + ") SET " + strings.Join(sets, ", "))
+
+	tx := c.db.WithContext(ctx).Exec(sql.String())
+	c.InvalidateTables(ctx, table)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("import: load data: %w", tx.Error)
+	}
+	return &ImportResult{Rows: tx.RowsAffected, Inserted: tx.RowsAffected}, nil
+}
+
+// loadDataValue 用户变量到列值的转换，处理 NULL 表示和 CSV 的 \r\n 换行
+func (src *importSource) loadDataValue(v string, last bool) string {
+	expr := v
+	if src.format == ExportCSV {
+		// ENCLOSED BY 非空时 LOAD DATA 把未加引号的 NULL 读为 NULL，批量插入时则是字符串 'NULL'，
+		// 这里还原为字符串，两种方式都只按 NullValue/EmptyAsNull 转换 NULL
+		expr = "COALESCE(" + expr + ", 'NULL')"
+		if last {
+			expr = "TRIM(TRAILING '\\r' FROM " + expr + ")"
+		}
+	}
+	if src.opts.EmptyAsNull {
+		expr = "NULLIF(" + expr + ", '')"
+	}
+	if src.null != "" && !(src.format == ExportTSV && src.null == `\N`) {
+		expr = "NULLIF(" + expr + ", " + quoteSQLString(src.null) + ")"
+	}
+	return expr
+}
+
+// quoteSQLString 生成 SQL 字符串字面量（LOAD DATA 不支持占位符）
+func quoteSQLString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
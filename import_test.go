@@ -0,0 +1,118 @@
+package grds
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportSourceCSV(t *testing.T) {
+	input := "\ufeffname,email\r\nalice,a@example.com\r\nNULL,\r\nbroken\r\n"
+	src, err := newImportSource(strings.NewReader(input), ExportCSV, &ImportOptions{EmptyAsNull: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"alice", "a@example.com"}, {"NULL", nil}}
+	for _, values := range want {
+		fields, got, err := src.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fields, []string{"name", "email"}) || !reflect.DeepEqual(got, values) {
+			t.Errorf("got %v %#v, want %#v", fields, got, values)
+		}
+	}
+	var formatErr *importFormatError
+	if _, _, err := src.next(); !errors.As(err, &formatErr) {
+		t.Errorf("short row: got %v, want format error", err)
+	}
+	if _, _, err := src.next(); err != io.EOF {
+		t.Errorf("got %v, want EOF", err)
+	}
+}
+
+func TestImportSourceTSV(t *testing.T) {
+	src, err := newImportSource(strings.NewReader("a\\tb\t\\N\n"), ExportTSV, &ImportOptions{NoHeader: true, Fields: []string{"x", "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, values, err := src.next()
+	if err != nil || !reflect.DeepEqual(values, []interface{}{"a\tb", nil}) {
+		t.Errorf("got %#v, %v", values, err)
+	}
+}
+
+func TestJSONImportValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want interface{}
+	}{
+		{`null`, nil},
+		{`true`, true},
+		{`"x\"y"`, `x"y`},
+		{`12345678901234567890`, "12345678901234567890"},
+		{`{"a":[1,2]}`, `{"a":[1,2]}`},
+	}
+	for _, tt := range tests {
+		got, err := jsonImportValue([]byte(tt.raw))
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("jsonImportValue(%s) = %#v, %v; want %#v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadDataValue(t *testing.T) {
+	tests := []struct {
+		format ExportFormat
+		opts   ImportOptions
+		last   bool
+		want   string
+	}{
+		{ExportTSV, ImportOptions{}, true, "@v"},
+		{ExportTSV, ImportOptions{NullValue: "-"}, false, "NULLIF(@v, '-')"},
+		{ExportCSV, ImportOptions{}, false, "COALESCE(@v, 'NULL')"},
+		{ExportCSV, ImportOptions{EmptyAsNull: true}, true, "NULLIF(TRIM(TRAILING '\\r' FROM COALESCE(@v, 'NULL')), '')"},
+		{ExportCSV, ImportOptions{NullValue: "NULL"}, false, "NULLIF(COALESCE(@v, 'NULL'), 'NULL')"},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		src, err := newImportSource(strings.NewReader("a\n"), tt.format, &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := src.loadDataValue("@v", tt.last); got != tt.want {
+			t.Errorf("%s %+v: got %q, want %q", tt.format, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestImportJSONLMissingKeys(t *testing.T) {
+	c := newTestClient(t)
+	input := `{"name":"a","age":1}` + "\n" + `{"age":2,"name":"b"}` + "\n" + `{"name":"c"}` + "\n"
+	src, err := newImportSource(strings.NewReader(input), ExportJSONL, &ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := &importer{
+		db:        c.DB().Table("users"),
+		opts:      &ImportOptions{},
+		batchSize: 10,
+		result:    &ImportResult{},
+	}
+	if err := imp.run(src); err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	for _, stmt := range c.rec.Statements() {
+		statements = append(statements, stmt.SQL)
+	}
+	want := []string{
+		"INSERT INTO `users` (`age`,`name`) VALUES (?,?),(?,?)",
+		"INSERT INTO `users` (`name`) VALUES (?)",
+	}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("got %q, want %q", statements, want)
+	}
+}
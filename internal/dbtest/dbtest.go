@@ -0,0 +1,138 @@
+// Package dbtest 提供不连接数据库的 GORM 测试连接
+//
+// Open 返回 DryRun 模式的连接，每条语句生成的 SQL 和参数由 Recorder 记录，
+// 测试据此断言生成的 SQL；Mock 返回由 sqlmock 驱动的连接，用于需要结果集或事务的测试。
+// 两者都无需 MySQL 服务。
+package dbtest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DSN 测试连接使用的数据源，DryRun 模式下不会真正连接
+const DSN = "grds:grds@tcp(127.0.0.1:3306)/grds_test"
+
+// Statement 记录的一条语句
+type Statement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// Recorder 记录连接上执行的语句
+type Recorder struct {
+	mu         sync.Mutex
+	statements []Statement
+}
+
+// Open 打开 DryRun 模式的 MySQL 连接，并返回记录其语句的 Recorder
+//
+// 连接跳过默认事务，Find、Update 等只生成 SQL；Rows、Row 等需要结果集的调用
+// 返回 gorm.ErrDryRunModeUnsupported，但 SQL 同样会被记录。
+func Open(t testing.TB) (*gorm.DB, *Recorder) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       DSN,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("dbtest: open: %v", err)
+	}
+
+	rec := &Recorder{}
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().After("*").Register("dbtest:record", rec.record),
+		callback.Query().After("*").Register("dbtest:record", rec.record),
+		callback.Update().After("*").Register("dbtest:record", rec.record),
+		callback.Delete().After("*").Register("dbtest:record", rec.record),
+		callback.Row().After("*").Register("dbtest:record", rec.record),
+		callback.Raw().After("*").Register("dbtest:record", rec.record),
+	} {
+		if err != nil {
+			t.Fatalf("dbtest: register callback: %v", err)
+		}
+	}
+	return db, rec
+}
+
+// Mock 打开由 sqlmock 驱动的 MySQL 连接，SQL 按原文精确匹配
+//
+// 连接跳过默认事务，测试结束时检查所有预期的语句都已执行。
+func Mock(t testing.TB) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("dbtest: sqlmock: %v", err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("dbtest: open: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = sqlDB.Close()
+	})
+	return db, mock
+}
+
+// record 记录语句生成的 SQL，未生成 SQL 的语句（如构建失败）不记录
+func (r *Recorder) record(db *gorm.DB) {
+	if db.Statement.SQL.Len() == 0 {
+		return
+	}
+	var vars []interface{}
+	if len(db.Statement.Vars) > 0 {
+		vars = make([]interface{}, len(db.Statement.Vars))
+		copy(vars, db.Statement.Vars)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, Statement{SQL: db.Statement.SQL.String(), Vars: vars})
+}
+
+// Statements 返回已记录的全部语句
+func (r *Recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := make([]Statement, len(r.statements))
+	copy(statements, r.statements)
+	return statements
+}
+
+// Last 返回最近记录的语句
+func (r *Recorder) Last() (Statement, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.statements) == 0 {
+		return Statement{}, false
+	}
+	return r.statements[len(r.statements)-1], true
+}
+
+// Reset 清空已记录的语句
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+}
@@ -0,0 +1,227 @@
+package grds
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// WhereJSON JSON 字段路径条件
+//
+// op 与 WhereStruct 相同：eq、ne、gt、gte、lt、lte 比较 JSON_EXTRACT 的结果；
+// like、in、notin 比较 JSON_UNQUOTE 后的字符串；null 的值为 bool，判断路径是否不存在。
+//
+//	grds.Table("products").WhereJSON("attrs", "$.size.width", grds.OpGt, 100)
+func (qb *QueryBuilder) WhereJSON(column, path, op string, value interface{}) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok || !qb.jsonPath(path) {
+		return qb
+	}
+
+	extract := "JSON_EXTRACT(" + col + ", ?)"
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		placeholder, arg, err := jsonArg(value)
+		if err != nil {
+			_ = qb.db.AddError(fmt.Errorf("where json: %w", err))
+			return qb
+		}
+		return qb.Where(extract+" "+comparisonOperators[op]+" "+placeholder, path, arg)
+	case OpLike, OpIn, OpNotIn:
+		return qb.Where("JSON_UNQUOTE("+extract+") "+comparisonOperators[op]+" ?", path, value)
+	case OpNull:
+		isNull, ok := value.(bool)
+		if !ok {
+			_ = qb.db.AddError(fmt.Errorf("where json: op null requires a bool, got %T", value))
+			return qb
+		}
+		if isNull {
+			return qb.Where(extract+" IS NULL", path)
+		}
+		return qb.Where(extract+" IS NOT NULL", path)
+	}
+	_ = qb.db.AddError(fmt.Errorf("where json: unsupported op %q", op))
+	return qb
+}
+
+// WhereJSONContains JSON_CONTAINS：path 处的文档包含 value，path 为空时检查整个文档
+//
+//	grds.Table("users").WhereJSONContains("tags", "", []string{"vip"})
+func (qb *QueryBuilder) WhereJSONContains(column, path string, value interface{}) *QueryBuilder {
+	return qb.jsonDocCondition("JSON_CONTAINS", column, path, value)
+}
+
+// WhereJSONOverlaps JSON_OVERLAPS：path 处的数组或对象与 value 有共同元素（MySQL 8.0.17+）
+func (qb *QueryBuilder) WhereJSONOverlaps(column, path string, value interface{}) *QueryBuilder {
+	return qb.jsonDocCondition("JSON_OVERLAPS", column, path, value)
+}
+
+// WhereJSONLength JSON_LENGTH 条件，path 为空时取整个文档的长度
+//
+//	grds.Table("users").WhereJSONLength("tags", "", grds.OpGte, 3)
+func (qb *QueryBuilder) WhereJSONLength(column, path, op string, length int) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok {
+		return qb
+	}
+	operator, ok := comparisonOperators[op]
+	if !ok || op == OpLike || op == OpIn || op == OpNotIn {
+		_ = qb.db.AddError(fmt.Errorf("where json length: unsupported op %q", op))
+		return qb
+	}
+	if path == "" {
+		return qb.Where("JSON_LENGTH("+col+") "+operator+" ?", length)
+	}
+	if !qb.jsonPath(path) {
+		return qb
+	}
+	return qb.Where("JSON_LENGTH("+col+", ?) "+operator+" ?", path, length)
+}
+
+// jsonDocCondition 生成 JSON_CONTAINS/JSON_OVERLAPS 条件
+func (qb *QueryBuilder) jsonDocCondition(fn, column, path string, value interface{}) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok {
+		return qb
+	}
+	doc, err := jsonDocument(value)
+	if err != nil {
+		_ = qb.db.AddError(fmt.Errorf("where json: %w", err))
+		return qb
+	}
+	if path == "" {
+		return qb.Where(fn+"("+col+", ?)", doc)
+	}
+	if !qb.jsonPath(path) {
+		return qb
+	}
+	if fn == "JSON_CONTAINS" {
+		return qb.Where(fn+"("+col+", ?, ?)", doc, path)
+	}
+	return qb.Where(fn+"(JSON_EXTRACT("+col+", ?), ?)", path, doc)
+}
+
+// jsonPath 校验 JSON 路径，失败时记录错误
+func (qb *QueryBuilder) jsonPath(path string) bool {
+	if !strings.HasPrefix(path, "$") {
+		_ = qb.db.AddError(fmt.Errorf("invalid json path %q: must start with $", path))
+		return false
+	}
+	return true
+}
+
+// UpdateJSONSet 用 JSON_SET 修改 JSON 字段中的一个路径，不读写整个文档
+//
+//	grds.Table("products").WhereEq("id", 1).UpdateJSONSet("attrs", "$.color", "red")
+func (qb *QueryBuilder) UpdateJSONSet(column, path string, value interface{}) error {
+	if !qb.jsonPath(path) {
+		return wrapError("update_json_set", qb.db)
+	}
+	if _, ok := qb.column(column); !ok {
+		return wrapError("update_json_set", qb.db)
+	}
+	return wrapError("update_json_set", qb.db.Update(column, JSONSet(column, path, value)))
+}
+
+// UpdateJSONRemove 用 JSON_REMOVE 删除 JSON 字段中的路径
+func (qb *QueryBuilder) UpdateJSONRemove(column string, paths ...string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("update json remove: at least one path is required")
+	}
+	for _, path := range paths {
+		if !qb.jsonPath(path) {
+			return wrapError("update_json_remove", qb.db)
+		}
+	}
+	if _, ok := qb.column(column); !ok {
+		return wrapError("update_json_remove", qb.db)
+	}
+	return wrapError("update_json_remove", qb.db.Update(column, JSONRemove(column, paths...)))
+}
+
+// JSONSet JSON_SET 表达式，可在 Updates 中与其他字段一起更新
+//
+//	qb.Updates(map[string]interface{}{"attrs": grds.JSONSet("attrs", "$.color", "red"), "status": 1})
+func JSONSet(column, path string, value interface{}) clause.Expression {
+	return jsonModify{fn: "JSON_SET", column: column, paths: []string{path}, value: value, hasValue: true}
+}
+
+// JSONRemove JSON_REMOVE 表达式
+func JSONRemove(column string, paths ...string) clause.Expression {
+	return jsonModify{fn: "JSON_REMOVE", column: column, paths: paths}
+}
+
+// jsonModify JSON_SET/JSON_REMOVE 表达式
+type jsonModify struct {
+	fn       string
+	column   string
+	paths    []string
+	value    interface{}
+	hasValue bool
+}
+
+// Build 生成 JSON_SET(`column`, ?, ?)
+func (e jsonModify) Build(builder clause.Builder) {
+	builder.WriteString(e.fn)
+	builder.WriteByte('(')
+	builder.WriteQuoted(e.column)
+	for _, path := range e.paths {
+		builder.WriteString(", ")
+		builder.AddVar(builder, path)
+		if e.hasValue {
+			placeholder, arg, err := jsonArg(e.value)
+			if err != nil {
+				_ = builder.AddError(fmt.Errorf("%s: %w", strings.ToLower(e.fn), err))
+				return
+			}
+			builder.WriteString(", ")
+			if placeholder == "?" {
+				builder.AddVar(builder, arg)
+			} else {
+				builder.WriteString("CAST(")
+				builder.AddVar(builder, arg)
+				builder.WriteString(" AS JSON)")
+			}
+		}
+	}
+	builder.WriteByte(')')
+}
+
+// jsonArg 把 Go 值转换为 JSON 函数的参数
+//
+// 字符串和数字直接绑定；bool、nil、map、切片和结构体序列化后用 CAST(? AS JSON) 绑定。
+func jsonArg(value interface{}) (string, interface{}, error) {
+	if value == nil {
+		return "CAST(? AS JSON)", "null", nil
+	}
+	if raw, ok := value.(json.RawMessage); ok {
+		return "CAST(? AS JSON)", string(raw), nil
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "?", value, nil
+	}
+	doc, err := jsonDocument(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return "CAST(? AS JSON)", doc, nil
+}
+
+// jsonDocument 把值序列化为 JSON 文本，json.RawMessage 原样使用
+func jsonDocument(value interface{}) (string, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		return string(raw), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
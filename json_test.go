@@ -0,0 +1,133 @@
+package grds
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhereJSONSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+		vars []interface{}
+	}{
+		{"eq number", c.Table("products").WhereJSON("attrs", "$.size.width", OpGt, 100),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) > ?", []interface{}{"$.size.width", 100}},
+		{"eq string", c.Table("products").WhereJSON("p.attrs", "$.color", OpEq, "red"),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`p`.`attrs`, ?) = ?", []interface{}{"$.color", "red"}},
+		{"eq bool", c.Table("products").WhereJSON("attrs", "$.sale", OpNe, true),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) != CAST(? AS JSON)", []interface{}{"$.sale", "true"}},
+		{"eq object", c.Table("products").WhereJSON("attrs", "$.size", OpEq, map[string]int{"w": 1}),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) = CAST(? AS JSON)", []interface{}{"$.size", `{"w":1}`}},
+		{"eq raw", c.Table("products").WhereJSON("attrs", "$.tags", OpEq, json.RawMessage(`["a"]`)),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) = CAST(? AS JSON)", []interface{}{"$.tags", `["a"]`}},
+		{"like", c.Table("products").WhereJSON("attrs", "$.name", OpLike, "%phone%"),
+			"SELECT * FROM `products` WHERE JSON_UNQUOTE(JSON_EXTRACT(`attrs`, ?)) LIKE ?", []interface{}{"$.name", "%phone%"}},
+		{"in", c.Table("products").WhereJSON("attrs", "$.color", OpIn, []string{"red", "blue"}),
+			"SELECT * FROM `products` WHERE JSON_UNQUOTE(JSON_EXTRACT(`attrs`, ?)) IN (?,?)", []interface{}{"$.color", "red", "blue"}},
+		{"is null", c.Table("products").WhereJSON("attrs", "$.color", OpNull, true),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) IS NULL", []interface{}{"$.color"}},
+		{"is not null", c.Table("products").WhereJSON("attrs", "$.color", OpNull, false),
+			"SELECT * FROM `products` WHERE JSON_EXTRACT(`attrs`, ?) IS NOT NULL", []interface{}{"$.color"}},
+		{"contains document", c.Table("users").WhereJSONContains("tags", "", []string{"vip"}),
+			"SELECT * FROM `users` WHERE JSON_CONTAINS(`tags`, ?)", []interface{}{`["vip"]`}},
+		{"contains path", c.Table("users").WhereJSONContains("profile", "$.roles", "admin"),
+			"SELECT * FROM `users` WHERE JSON_CONTAINS(`profile`, ?, ?)", []interface{}{`"admin"`, "$.roles"}},
+		{"overlaps path", c.Table("users").WhereJSONOverlaps("profile", "$.roles", []string{"a", "b"}),
+			"SELECT * FROM `users` WHERE JSON_OVERLAPS(JSON_EXTRACT(`profile`, ?), ?)", []interface{}{"$.roles", `["a","b"]`}},
+		{"length", c.Table("users").WhereJSONLength("tags", "", OpGte, 3),
+			"SELECT * FROM `users` WHERE JSON_LENGTH(`tags`) >= ?", []interface{}{3}},
+		{"length path", c.Table("users").WhereJSONLength("profile", "$.roles", OpEq, 0),
+			"SELECT * FROM `users` WHERE JSON_LENGTH(`profile`, ?) = ?", []interface{}{"$.roles", 0}},
+	}
+	for _, tt := range tests {
+		sql, vars := toSQL(t, tt.qb, SQLFind)
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestUpdateJSONSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+		vars []interface{}
+	}{
+		{"set scalar", func() error {
+			return c.Table("products").WhereEq("id", 1).UpdateJSONSet("attrs", "$.color", "red")
+		}, "UPDATE `products` SET `attrs`=JSON_SET(`attrs`, ?, ?) WHERE `id` = ?", []interface{}{"$.color", "red", 1}},
+		{"set document", func() error {
+			return c.Table("products").WhereEq("id", 1).UpdateJSONSet("attrs", "$.size", map[string]int{"w": 2})
+		}, "UPDATE `products` SET `attrs`=JSON_SET(`attrs`, ?, CAST(? AS JSON)) WHERE `id` = ?", []interface{}{"$.size", `{"w":2}`, 1}},
+		{"set null", func() error {
+			return c.Table("products").WhereEq("id", 1).UpdateJSONSet("attrs", "$.size", nil)
+		}, "UPDATE `products` SET `attrs`=JSON_SET(`attrs`, ?, CAST(? AS JSON)) WHERE `id` = ?", []interface{}{"$.size", "null", 1}},
+		{"remove paths", func() error {
+			return c.Table("products").WhereEq("id", 1).UpdateJSONRemove("attrs", "$.a", "$.b")
+		}, "UPDATE `products` SET `attrs`=JSON_REMOVE(`attrs`, ?, ?) WHERE `id` = ?", []interface{}{"$.a", "$.b", 1}},
+		{"set in updates", func() error {
+			return c.Table("products").WhereEq("id", 1).Updates(map[string]interface{}{
+				"attrs":  JSONSet("attrs", "$.color", "red"),
+				"status": 2,
+			})
+		}, "UPDATE `products` SET `attrs`=JSON_SET(`attrs`, ?, ?),`status`=? WHERE `id` = ?", []interface{}{"$.color", "red", 2, 1}},
+	}
+	for _, tt := range tests {
+		sql, vars := c.lastSQL(t, tt.run())
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		run  func() error
+		err  string
+	}{
+		{"path prefix", func() error {
+			_, _, err := c.Table("products").WhereJSON("attrs", "color", OpEq, 1).ToSQL(SQLFind)
+			return err
+		}, "must start with $"},
+		{"unsupported op", func() error {
+			_, _, err := c.Table("products").WhereJSON("attrs", "$.a", OpBetween, 1).ToSQL(SQLFind)
+			return err
+		}, `unsupported op "between"`},
+		{"null requires bool", func() error {
+			_, _, err := c.Table("products").WhereJSON("attrs", "$.a", OpNull, 1).ToSQL(SQLFind)
+			return err
+		}, "op null requires a bool"},
+		{"unencodable value", func() error {
+			_, _, err := c.Table("products").WhereJSON("attrs", "$.a", OpEq, map[string]interface{}{"f": func() {}}).ToSQL(SQLFind)
+			return err
+		}, "unsupported type"},
+		{"invalid column", func() error {
+			_, _, err := c.Table("products").WhereJSONContains("attrs)", "", 1).ToSQL(SQLFind)
+			return err
+		}, "invalid identifier"},
+		{"length op", func() error {
+			_, _, err := c.Table("products").WhereJSONLength("attrs", "", OpLike, 1).ToSQL(SQLFind)
+			return err
+		}, `unsupported op "like"`},
+		{"remove without path", func() error {
+			return c.Table("products").UpdateJSONRemove("attrs")
+		}, "at least one path is required"},
+		{"set invalid column", func() error {
+			return c.Table("products").UpdateJSONSet("attrs;", "$.a", 1)
+		}, "invalid identifier"},
+	}
+	for _, tt := range tests {
+		if err := tt.run(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package grds
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 查询字符串中的保留参数名
+const (
+	QueryParamSort = "sort" // 排序，如 sort=-created_at,id
+	QueryParamPage = "page" // 页码，从 1 开始
+	QueryParamSize = "size" // 每页数量
+)
+
+// 分页默认值
+const (
+	DefaultQueryPageSize = 20
+	DefaultQueryMaxSize  = 100
+)
+
+// QueryFieldType 查询参数值类型，决定参数值的类型转换
+type QueryFieldType int
+
+const (
+	FieldString QueryFieldType = iota // 字符串
+	FieldInt                          // 整数
+	FieldFloat                        // 浮点数
+	FieldBool                         // 布尔值
+	FieldTime                         // 时间，支持 RFC3339、2006-01-02 15:04:05 和 2006-01-02
+)
+
+// QueryField 允许通过查询字符串过滤或排序的字段
+type QueryField struct {
+	Column   string         // 数据库列名，默认与参数名相同
+	Type     QueryFieldType // 值类型
+	Ops      []string       // 允许的操作符（见 OpEq 等常量），为空时只允许 eq
+	Sortable bool           // 是否允许排序
+}
+
+// QueryAllowlist 查询字符串允许列表
+type QueryAllowlist struct {
+	Fields          map[string]QueryField // 参数名 -> 字段
+	DefaultPageSize int                   // 默认每页数量，默认 20
+	MaxPageSize     int                   // 每页数量上限，默认 100
+	IgnoreUnknown   bool                  // 忽略未声明的参数，默认返回错误
+}
+
+// QueryFilter 解析出的过滤条件
+type QueryFilter struct {
+	Param  string      // 参数名
+	Column string      // 列名
+	Op     string      // 操作符
+	Value  interface{} // 转换后的值，in/notin/between 为 []interface{}
+}
+
+// QuerySort 解析出的排序
+type QuerySort struct {
+	Column string
+	Desc   bool
+}
+
+// ParsedQuery 查询字符串解析结果
+type ParsedQuery struct {
+	Filters  []QueryFilter
+	Sorts    []QuerySort
+	Page     int
+	PageSize int
+}
+
+// QueryParamError 查询参数错误，指向出错的参数，可直接用于返回 400
+type QueryParamError struct {
+	Param  string // 参数名
+	Value  string // 原始值
+	Reason string // 原因
+}
+
+// Error 实现 error 接口
+func (e *QueryParamError) Error() string {
+	return fmt.Sprintf("invalid query parameter %q: %s", e.Param, e.Reason)
+}
+
+// QueryParamErrors 多个查询参数错误
+type QueryParamErrors []*QueryParamError
+
+// Error 实现 error 接口
+func (es QueryParamErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ParseQuery 解析 HTTP 查询字符串中的过滤、排序和分页参数
+//
+// 过滤参数格式为 name=op:value，省略操作符时为 eq，例如
+// ?status=eq:1&age=gte:18&name=like:jo%&id=in:1,2,3&created_at=between:2024-01-01,2024-02-01&sort=-created_at,id&page=2&size=20。
+// 只接受 allowlist 中声明的字段和操作符，值按字段类型转换。
+// 所有参数错误汇总为 QueryParamErrors 返回。
+func ParseQuery(values url.Values, allowlist *QueryAllowlist) (*ParsedQuery, error) {
+	if allowlist == nil {
+		allowlist = &QueryAllowlist{}
+	}
+
+	pq := &ParsedQuery{Page: 1, PageSize: allowlist.DefaultPageSize}
+	if pq.PageSize <= 0 {
+		pq.PageSize = DefaultQueryPageSize
+	}
+	maxSize := allowlist.MaxPageSize
+	if maxSize <= 0 {
+		maxSize = DefaultQueryMaxSize
+	}
+
+	var errs QueryParamErrors
+	fail := func(param, value, format string, args ...interface{}) {
+		errs = append(errs, &QueryParamError{Param: param, Value: value, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	// 按参数名排序，保证条件和错误顺序稳定
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		for _, raw := range values[param] {
+			switch param {
+			case QueryParamSort:
+				for _, item := range strings.Split(raw, ",") {
+					item = strings.TrimSpace(item)
+					if item == "" {
+						continue
+					}
+					desc := strings.HasPrefix(item, "-")
+					name := strings.TrimLeft(item, "+-")
+					field, ok := allowlist.Fields[name]
+					if !ok || !field.Sortable {
+						fail(param, raw, "sorting by %q is not allowed", name)
+						continue
+					}
+					pq.Sorts = append(pq.Sorts, QuerySort{Column: field.column(name), Desc: desc})
+				}
+			case QueryParamPage:
+				page, err := strconv.Atoi(raw)
+				if err != nil || page < 1 {
+					fail(param, raw, "must be a positive integer")
+					continue
+				}
+				pq.Page = page
+			case QueryParamSize:
+				size, err := strconv.Atoi(raw)
+				if err != nil || size < 1 || size > maxSize {
+					fail(param, raw, "must be an integer between 1 and %d", maxSize)
+					continue
+				}
+				pq.PageSize = size
+			default:
+				field, ok := allowlist.Fields[param]
+				if !ok {
+					if !allowlist.IgnoreUnknown {
+						fail(param, raw, "unknown filter")
+					}
+					continue
+				}
+				filter, err := field.parse(param, raw)
+				if err != nil {
+					fail(param, raw, "%s", err)
+					continue
+				}
+				pq.Filters = append(pq.Filters, filter)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return pq, nil
+}
+
+// Apply 把解析结果应用到查询构建器
+func (pq *ParsedQuery) Apply(qb *QueryBuilder) *QueryBuilder {
+	for _, f := range pq.Filters {
+		expr, err := qb.filterCondition(f.Column, f.Op, reflect.ValueOf(f.Value))
+		if err != nil {
+			_ = qb.db.AddError(&QueryParamError{Param: f.Param, Value: fmt.Sprint(f.Value), Reason: err.Error()})
+			return qb
+		}
+		if expr != nil {
+			qb.db = qb.db.Where(expr)
+		}
+	}
+	for _, s := range pq.Sorts {
+		if s.Desc {
+			qb.OrderByDesc(s.Column)
+		} else {
+			qb.OrderByAsc(s.Column)
+		}
+	}
+	if pq.PageSize > 0 {
+		qb.Page(pq.Page, pq.PageSize)
+	}
+	return qb
+}
+
+// ApplyQuery 应用 ParseQuery 的解析结果
+func (qb *QueryBuilder) ApplyQuery(pq *ParsedQuery) *QueryBuilder {
+	if pq == nil {
+		return qb
+	}
+	return pq.Apply(qb)
+}
+
+// column 返回字段对应的列名
+func (f QueryField) column(param string) string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return param
+}
+
+// allows 检查字段是否允许该操作符
+func (f QueryField) allows(op string) bool {
+	if len(f.Ops) == 0 {
+		return op == OpEq
+	}
+	for _, allowed := range f.Ops {
+		if strings.EqualFold(allowed, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse 解析 op:value 形式的参数值
+func (f QueryField) parse(param, raw string) (QueryFilter, error) {
+	op, value := OpEq, raw
+	if i := strings.IndexByte(raw, ':'); i > 0 {
+		if _, ok := queryOps[strings.ToLower(raw[:i])]; ok {
+			op, value = strings.ToLower(raw[:i]), raw[i+1:]
+		}
+	}
+	if !f.allows(op) {
+		return QueryFilter{}, fmt.Errorf("operator %q is not allowed", op)
+	}
+
+	filter := QueryFilter{Param: param, Column: f.column(param), Op: op}
+	switch op {
+	case OpIn, OpNotIn, OpBetween:
+		parts := strings.Split(value, ",")
+		if op == OpBetween && len(parts) != 2 {
+			return QueryFilter{}, fmt.Errorf("between requires two comma separated values")
+		}
+		list := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			v, err := f.convert(strings.TrimSpace(part))
+			if err != nil {
+				return QueryFilter{}, err
+			}
+			list = append(list, v)
+		}
+		filter.Value = list
+	case OpNull:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return QueryFilter{}, fmt.Errorf("null requires true or false")
+		}
+		filter.Value = b
+	case OpLike:
+		filter.Value = value
+	default:
+		v, err := f.convert(value)
+		if err != nil {
+			return QueryFilter{}, err
+		}
+		filter.Value = v
+	}
+	return filter, nil
+}
+
+// convert 按字段类型转换值
+func (f QueryField) convert(value string) (interface{}, error) {
+	switch f.Type {
+	case FieldInt:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return v, nil
+	case FieldFloat:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return v, nil
+	case FieldBool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return v, nil
+	case FieldTime:
+		for _, layout := range queryTimeLayouts {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a valid time", value)
+	}
+	return value, nil
+}
+
+// queryOps 查询字符串支持的操作符
+var queryOps = map[string]struct{}{
+	OpEq: {}, OpNe: {}, OpGt: {}, OpGte: {}, OpLt: {}, OpLte: {},
+	OpLike: {}, OpIn: {}, OpNotIn: {}, OpBetween: {}, OpNull: {},
+}
+
+// queryTimeLayouts FieldTime 支持的时间格式
+var queryTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
@@ -0,0 +1,138 @@
+package grds
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testAllowlist() *QueryAllowlist {
+	return &QueryAllowlist{
+		Fields: map[string]QueryField{
+			"status":     {Type: FieldInt, Ops: []string{OpEq, OpIn}},
+			"age":        {Type: FieldInt, Ops: []string{OpGte, OpLte, OpBetween}, Sortable: true},
+			"name":       {Ops: []string{OpEq, OpLike}},
+			"active":     {Type: FieldBool},
+			"score":      {Type: FieldFloat, Ops: []string{OpGt}},
+			"deleted":    {Column: "deleted_at", Ops: []string{OpNull}},
+			"created_at": {Type: FieldTime, Ops: []string{OpGte}, Sortable: true},
+		},
+		MaxPageSize: 50,
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  *ParsedQuery
+	}{
+		{"", &ParsedQuery{Page: 1, PageSize: DefaultQueryPageSize}},
+		{"status=1", &ParsedQuery{
+			Filters: []QueryFilter{{Param: "status", Column: "status", Op: OpEq, Value: int64(1)}},
+			Page:    1, PageSize: DefaultQueryPageSize,
+		}},
+		{"status=in:1,2&name=like:jo%25", &ParsedQuery{
+			Filters: []QueryFilter{
+				{Param: "name", Column: "name", Op: OpLike, Value: "jo%"},
+				{Param: "status", Column: "status", Op: OpIn, Value: []interface{}{int64(1), int64(2)}},
+			},
+			Page: 1, PageSize: DefaultQueryPageSize,
+		}},
+		{"age=between:18,30&score=gt:1.5&active=true&deleted=null:true", &ParsedQuery{
+			Filters: []QueryFilter{
+				{Param: "active", Column: "active", Op: OpEq, Value: true},
+				{Param: "age", Column: "age", Op: OpBetween, Value: []interface{}{int64(18), int64(30)}},
+				{Param: "deleted", Column: "deleted_at", Op: OpNull, Value: true},
+				{Param: "score", Column: "score", Op: OpGt, Value: 1.5},
+			},
+			Page: 1, PageSize: DefaultQueryPageSize,
+		}},
+		{"name=a:b", &ParsedQuery{
+			Filters: []QueryFilter{{Param: "name", Column: "name", Op: OpEq, Value: "a:b"}},
+			Page:    1, PageSize: DefaultQueryPageSize,
+		}},
+		{"created_at=gte:2024-01-02", &ParsedQuery{
+			Filters: []QueryFilter{{Param: "created_at", Column: "created_at", Op: OpGte,
+				Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)}},
+			Page: 1, PageSize: DefaultQueryPageSize,
+		}},
+		{"sort=-created_at,age&page=3&size=50", &ParsedQuery{
+			Sorts: []QuerySort{{Column: "created_at", Desc: true}, {Column: "age"}},
+			Page:  3, PageSize: 50,
+		}},
+	}
+	for _, tt := range tests {
+		values, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseQuery(values, testAllowlist())
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		params []string
+	}{
+		{"unknown=1", []string{"unknown"}},
+		{"status=gt:1", []string{"status"}},
+		{"status=abc", []string{"status"}},
+		{"age=between:1", []string{"age"}},
+		{"active=yes", []string{"active"}},
+		{"deleted=null:maybe", []string{"deleted"}},
+		{"created_at=gte:yesterday", []string{"created_at"}},
+		{"sort=name", []string{"sort"}},
+		{"page=0&size=51", []string{"page", "size"}},
+		{"size=x&status=x&age=gte:x", []string{"age", "size", "status"}},
+	}
+	for _, tt := range tests {
+		values, _ := url.ParseQuery(tt.query)
+		_, err := ParseQuery(values, testAllowlist())
+		var errs QueryParamErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("ParseQuery(%q) error = %v, want QueryParamErrors", tt.query, err)
+			continue
+		}
+		params := make([]string, 0, len(errs))
+		for _, e := range errs {
+			params = append(params, e.Param)
+		}
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("ParseQuery(%q) failed params = %v, want %v", tt.query, params, tt.params)
+		}
+	}
+
+	allowlist := testAllowlist()
+	allowlist.IgnoreUnknown = true
+	if _, err := ParseQuery(url.Values{"unknown": {"1"}}, allowlist); err != nil {
+		t.Errorf("IgnoreUnknown: %v", err)
+	}
+}
+
+func TestParsedQueryApply(t *testing.T) {
+	c := newTestClient(t)
+	values, _ := url.ParseQuery("status=in:1,2&age=gte:18&sort=-age&page=2&size=10")
+	pq, err := ParseQuery(values, testAllowlist())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]interface{}
+	sql, vars := c.lastSQL(t, c.Table("users").ApplyQuery(pq).Find(&rows))
+	want := "SELECT * FROM `users` WHERE `age` >= ? AND `status` IN (?,?) ORDER BY `age` DESC LIMIT 10 OFFSET 10"
+	if sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if wantVars := []interface{}{int64(18), int64(1), int64(2)}; !reflect.DeepEqual(vars, wantVars) {
+		t.Errorf("vars = %v, want %v", vars, wantVars)
+	}
+}
@@ -1,6 +1,8 @@
 package grds
 
 import (
+	"strings"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -9,6 +11,8 @@ import (
 type QueryBuilder struct {
 	client *Client
 	db     *gorm.DB
+
+	allowedColumns map[string]bool // 允许使用的列，nil 表示不限制
 }
 
 // DB 获取底层的 GORM DB
@@ -49,26 +53,73 @@ func (qb *QueryBuilder) Order(value interface{}) *QueryBuilder {
 	return qb
 }
 
-// OrderBy 排序（别名）
-func (qb *QueryBuilder) OrderBy(column string) *QueryBuilder {
-	return qb.Order(column)
+// OrderBy 排序，逗号分隔多列，每列可带 ASC/DESC 后缀，如 "age DESC, id"
+func (qb *QueryBuilder) OrderBy(columns string) *QueryBuilder {
+	items := make([]string, 0, 2)
+	for _, item := range strings.Split(columns, ",") {
+		order, ok := qb.orderItem(item)
+		if !ok {
+			return qb
+		}
+		items = append(items, order)
+	}
+	return qb.Order(strings.Join(items, ", "))
+}
+
+// orderItem 校验 "column [ASC|DESC]" 并返回转义结果
+func (qb *QueryBuilder) orderItem(item string) (string, bool) {
+	fields := strings.Fields(item)
+	if len(fields) == 2 {
+		switch direction := strings.ToUpper(fields[1]); direction {
+		case "ASC", "DESC":
+			col, ok := qb.column(fields[0])
+			return col + " " + direction, ok
+		}
+	}
+	return qb.column(item)
+}
+
+// OrderByRaw 原样使用排序表达式，不校验，不能拼接用户输入；OrderBy 只接受列名
+//
+//	qb.OrderByRaw("RAND()")
+//	qb.OrderByRaw("FIELD(status, ?, ?)", "paid", "pending")
+func (qb *QueryBuilder) OrderByRaw(sql string, args ...interface{}) *QueryBuilder {
+	if len(args) == 0 {
+		return qb.Order(sql)
+	}
+	qb.db = qb.db.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: args, WithoutParentheses: true}})
+	return qb
 }
 
 // OrderByAsc 升序排序
 func (qb *QueryBuilder) OrderByAsc(column string) *QueryBuilder {
-	return qb.Order(column + " ASC")
+	if col, ok := qb.column(column); ok {
+		return qb.Order(col + " ASC")
+	}
+	return qb
 }
 
 // OrderByDesc 降序排序
 func (qb *QueryBuilder) OrderByDesc(column string) *QueryBuilder {
-	return qb.Order(column + " DESC")
+	if col, ok := qb.column(column); ok {
+		return qb.Order(col + " DESC")
+	}
+	return qb
 }
 
 // ==================== 分组 ====================
 
-// GroupBy 分组
-func (qb *QueryBuilder) GroupBy(name string) *QueryBuilder {
-	qb.db = qb.db.Group(name)
+// GroupBy 按列分组，逗号分隔多列（校验列名）
+func (qb *QueryBuilder) GroupBy(columns string) *QueryBuilder {
+	quoted := make([]string, 0, 2)
+	for _, column := range strings.Split(columns, ",") {
+		col, ok := qb.column(column)
+		if !ok {
+			return qb
+		}
+		quoted = append(quoted, col)
+	}
+	qb.db = qb.db.Group(strings.Join(quoted, ", "))
 	return qb
 }
 
@@ -116,18 +167,30 @@ func (qb *QueryBuilder) Joins(query string, args ...interface{}) *QueryBuilder {
 }
 
 // LeftJoin 左连接
+//
+// tableName 支持 "table alias" 形式并会被校验；condition 按原样拼接，不能包含外部输入。
 func (qb *QueryBuilder) LeftJoin(tableName string, condition string) *QueryBuilder {
-	return qb.Joins("LEFT JOIN " + tableName + " ON " + condition)
+	return qb.join("LEFT JOIN", tableName, condition)
 }
 
 // RightJoin 右连接
 func (qb *QueryBuilder) RightJoin(tableName string, condition string) *QueryBuilder {
-	return qb.Joins("RIGHT JOIN " + tableName + " ON " + condition)
+	return qb.join("RIGHT JOIN", tableName, condition)
 }
 
 // InnerJoin 内连接
 func (qb *QueryBuilder) InnerJoin(tableName string, condition string) *QueryBuilder {
-	return qb.Joins("INNER JOIN " + tableName + " ON " + condition)
+	return qb.join("INNER JOIN", tableName, condition)
+}
+
+// join 校验表名后添加连接
+func (qb *QueryBuilder) join(kind, tableName, condition string) *QueryBuilder {
+	table, err := quoteTableAlias(tableName)
+	if err != nil {
+		_ = qb.db.AddError(err)
+		return qb
+	}
+	return qb.Joins(kind + " " + table + " ON " + condition)
 }
 
 // ==================== 选择字段 ====================
@@ -178,128 +241,184 @@ func (qb *QueryBuilder) ForShare() *QueryBuilder {
 	return qb
 }
 
+// ForUpdateNoWait 行锁，行已被锁定时立即返回 ErrLockNotAvailable（FOR UPDATE NOWAIT）
+func (qb *QueryBuilder) ForUpdateNoWait() *QueryBuilder {
+	return qb.lockForUpdate(func(l *clause.Locking) { l.Options = "NOWAIT" })
+}
+
+// ForUpdateSkipLocked 行锁，跳过已被锁定的行（FOR UPDATE SKIP LOCKED），适合多个 worker 并发领取任务
+func (qb *QueryBuilder) ForUpdateSkipLocked() *QueryBuilder {
+	return qb.lockForUpdate(func(l *clause.Locking) { l.Options = "SKIP LOCKED" })
+}
+
+// ForUpdateOf 只锁定指定表（或别名）的行（FOR UPDATE OF t1, t2），可与 NOWAIT / SKIP LOCKED 组合
+//
+//	grds.Table("orders o").InnerJoin("users u", "u.id = o.user_id").ForUpdateOf("o").ForUpdateSkipLocked()
+func (qb *QueryBuilder) ForUpdateOf(tables ...string) *QueryBuilder {
+	quoted := make([]string, 0, len(tables))
+	for _, table := range tables {
+		q, err := QuoteIdentifier(table)
+		if err != nil {
+			_ = qb.db.AddError(err)
+			return qb
+		}
+		quoted = append(quoted, q)
+	}
+	return qb.lockForUpdate(func(l *clause.Locking) {
+		l.Table = clause.Table{Name: strings.Join(quoted, ", "), Raw: true}
+	})
+}
+
+// lockForUpdate 在已有的 FOR UPDATE 子句上修改选项
+func (qb *QueryBuilder) lockForUpdate(modify func(*clause.Locking)) *QueryBuilder {
+	locking := clause.Locking{}
+	if c, ok := qb.db.Statement.Clauses["FOR"]; ok {
+		if prev, ok := c.Expression.(clause.Locking); ok {
+			locking = prev
+		}
+	}
+	locking.Strength = "UPDATE"
+	modify(&locking)
+	qb.db = qb.db.Clauses(locking)
+	return qb
+}
+
 // ==================== 查询操作 ====================
 
 // Find 查询多条记录
 func (qb *QueryBuilder) Find(dest interface{}, conds ...interface{}) error {
-	return qb.db.Find(dest, conds...).Error
+	return wrapError("find", qb.db.Find(dest, conds...))
 }
 
 // First 查询第一条记录
 func (qb *QueryBuilder) First(dest interface{}, conds ...interface{}) error {
-	return qb.db.First(dest, conds...).Error
+	return wrapError("first", qb.db.First(dest, conds...))
 }
 
 // Last 查询最后一条记录
 func (qb *QueryBuilder) Last(dest interface{}, conds ...interface{}) error {
-	return qb.db.Last(dest, conds...).Error
+	return wrapError("last", qb.db.Last(dest, conds...))
 }
 
 // Take 随机获取一条记录
 func (qb *QueryBuilder) Take(dest interface{}, conds ...interface{}) error {
-	return qb.db.Take(dest, conds...).Error
+	return wrapError("take", qb.db.Take(dest, conds...))
 }
 
 // Scan 扫描结果到目标
 func (qb *QueryBuilder) Scan(dest interface{}) error {
-	return qb.db.Scan(dest).Error
+	return wrapError("scan", qb.db.Scan(dest))
 }
 
-// Pluck 查询单列
+// Pluck 查询单列（校验列名）
 func (qb *QueryBuilder) Pluck(column string, dest interface{}) error {
-	return qb.db.Pluck(column, dest).Error
+	if _, ok := qb.column(column); !ok {
+		return wrapError("pluck", qb.db)
+	}
+	return wrapError("pluck", qb.db.Pluck(strings.TrimSpace(column), dest))
 }
 
 // Count 统计数量
 func (qb *QueryBuilder) Count() (int64, error) {
 	var count int64
-	err := qb.db.Count(&count).Error
-	return count, err
+	tx := qb.db.Count(&count)
+	return count, wrapError("count", tx)
 }
 
-// Exists 检查是否存在
+// Exists 检查是否存在，使用 SELECT 1 ... LIMIT 1，不统计全部行
 func (qb *QueryBuilder) Exists() (bool, error) {
-	count, err := qb.Count()
-	if err != nil {
-		return false, err
+	var one int
+	tx := qb.db.Session(&gorm.Session{}).Select("1").Limit(1)
+	delete(tx.Statement.Clauses, "ORDER BY")
+	tx = tx.Scan(&one)
+	if tx.Error != nil {
+		return false, wrapError("exists", tx)
 	}
-	return count > 0, nil
+	return tx.RowsAffected > 0, nil
 }
 
 // ==================== 创建操作 ====================
 
 // Create 创建记录
 func (qb *QueryBuilder) Create(value interface{}) error {
-	return qb.db.Create(value).Error
+	return wrapError("create", qb.db.Create(value))
 }
 
 // CreateInBatches 批量创建
 func (qb *QueryBuilder) CreateInBatches(value interface{}, batchSize int) error {
-	return qb.db.CreateInBatches(value, batchSize).Error
+	return wrapError("create_in_batches", qb.db.CreateInBatches(value, batchSize))
 }
 
 // ==================== 更新操作 ====================
 
 // Update 更新单个字段
 func (qb *QueryBuilder) Update(column string, value interface{}) error {
-	return qb.db.Update(column, value).Error
+	return wrapError("update", qb.db.Update(column, value))
 }
 
 // Updates 更新多个字段
 func (qb *QueryBuilder) Updates(values interface{}) error {
-	return qb.db.Updates(values).Error
+	return wrapError("updates", qb.db.Updates(values))
 }
 
 // UpdateColumn 更新单列（不触发钩子）
 func (qb *QueryBuilder) UpdateColumn(column string, value interface{}) error {
-	return qb.db.UpdateColumn(column, value).Error
+	return wrapError("update_column", qb.db.UpdateColumn(column, value))
 }
 
 // UpdateColumns 更新多列（不触发钩子）
 func (qb *QueryBuilder) UpdateColumns(values interface{}) error {
-	return qb.db.UpdateColumns(values).Error
+	return wrapError("update_columns", qb.db.UpdateColumns(values))
 }
 
 // Save 保存所有字段
 func (qb *QueryBuilder) Save(value interface{}) error {
-	return qb.db.Save(value).Error
+	return wrapError("save", qb.db.Save(value))
 }
 
 // ==================== 删除操作 ====================
 
 // Delete 删除记录
 func (qb *QueryBuilder) Delete(value interface{}, conds ...interface{}) error {
-	return qb.db.Delete(value, conds...).Error
+	return wrapError("delete", qb.db.Delete(value, conds...))
 }
 
 // ==================== 聚合函数 ====================
 
-// Sum 求和
+// Sum 求和，空集合返回 0
 func (qb *QueryBuilder) Sum(column string) (float64, error) {
-	var result float64
-	err := qb.db.Select("SUM(" + column + ")").Scan(&result).Error
-	return result, err
+	res, err := qb.Aggregate(Sum(column).As("result"))
+	if err != nil {
+		return 0, err
+	}
+	return res.Float64("result"), nil
 }
 
-// Avg 平均值
+// Avg 平均值，空集合返回 0
 func (qb *QueryBuilder) Avg(column string) (float64, error) {
-	var result float64
-	err := qb.db.Select("AVG(" + column + ")").Scan(&result).Error
-	return result, err
+	res, err := qb.Aggregate(Avg(column).As("result"))
+	if err != nil {
+		return 0, err
+	}
+	return res.Float64("result"), nil
 }
 
-// Max 最大值
+// Max 最大值，值已按列类型转换，空集合返回 nil
 func (qb *QueryBuilder) Max(column string) (interface{}, error) {
-	var result interface{}
-	err := qb.db.Select("MAX(" + column + ")").Scan(&result).Error
-	return result, err
+	res, err := qb.Aggregate(Max(column).As("result"))
+	if err != nil {
+		return nil, err
+	}
+	return res.Value("result"), nil
 }
 
-// Min 最小值
+// Min 最小值，值已按列类型转换，空集合返回 nil
 func (qb *QueryBuilder) Min(column string) (interface{}, error) {
-	var result interface{}
-	err := qb.db.Select("MIN(" + column + ")").Scan(&result).Error
-	return result, err
+	res, err := qb.Aggregate(Min(column).As("result"))
+	if err != nil {
+		return nil, err
+	}
+	return res.Value("result"), nil
 }
 
 // ==================== 其他操作 ====================
@@ -312,12 +431,13 @@ func (qb *QueryBuilder) Raw(sql string, values ...interface{}) *QueryBuilder {
 
 // Exec 执行 SQL
 func (qb *QueryBuilder) Exec(sql string, values ...interface{}) error {
-	return qb.db.Exec(sql, values...).Error
+	return wrapError("exec", qb.db.Exec(sql, values...))
 }
 
 // Model 指定模型
 func (qb *QueryBuilder) Model(value interface{}) *QueryBuilder {
 	qb.db = qb.db.Model(value)
+	qb.useModelAllowlist(value)
 	return qb
 }
 
@@ -358,8 +478,9 @@ func (qb *QueryBuilder) RowsAffected() int64 {
 // Clone 克隆查询构建器
 func (qb *QueryBuilder) Clone() *QueryBuilder {
 	return &QueryBuilder{
-		client: qb.client,
-		db:     qb.db.Session(&gorm.Session{NewDB: true}),
+		client:         qb.client,
+		db:             qb.db.Session(&gorm.Session{NewDB: true}),
+		allowedColumns: qb.allowedColumns,
 	}
 }
 
@@ -377,60 +498,96 @@ func (qb *QueryBuilder) FindAll(dest interface{}) error {
 
 // WhereEq 等于条件
 func (qb *QueryBuilder) WhereEq(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" = ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" = ?", value)
+	}
+	return qb
 }
 
 // WhereNe 不等于条件
 func (qb *QueryBuilder) WhereNe(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" != ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" != ?", value)
+	}
+	return qb
 }
 
 // WhereGt 大于条件
 func (qb *QueryBuilder) WhereGt(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" > ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" > ?", value)
+	}
+	return qb
 }
 
 // WhereGte 大于等于条件
 func (qb *QueryBuilder) WhereGte(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" >= ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" >= ?", value)
+	}
+	return qb
 }
 
 // WhereLt 小于条件
 func (qb *QueryBuilder) WhereLt(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" < ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" < ?", value)
+	}
+	return qb
 }
 
 // WhereLte 小于等于条件
 func (qb *QueryBuilder) WhereLte(column string, value interface{}) *QueryBuilder {
-	return qb.Where(column+" <= ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" <= ?", value)
+	}
+	return qb
 }
 
 // WhereLike LIKE 条件
 func (qb *QueryBuilder) WhereLike(column string, value string) *QueryBuilder {
-	return qb.Where(column+" LIKE ?", value)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" LIKE ?", value)
+	}
+	return qb
 }
 
 // WhereIn IN 条件
 func (qb *QueryBuilder) WhereIn(column string, values interface{}) *QueryBuilder {
-	return qb.Where(column+" IN ?", values)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" IN ?", values)
+	}
+	return qb
 }
 
 // WhereNotIn NOT IN 条件
 func (qb *QueryBuilder) WhereNotIn(column string, values interface{}) *QueryBuilder {
-	return qb.Where(column+" NOT IN ?", values)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" NOT IN ?", values)
+	}
+	return qb
 }
 
 // WhereBetween BETWEEN 条件
 func (qb *QueryBuilder) WhereBetween(column string, start, end interface{}) *QueryBuilder {
-	return qb.Where(column+" BETWEEN ? AND ?", start, end)
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col+" BETWEEN ? AND ?", start, end)
+	}
+	return qb
 }
 
 // WhereNull IS NULL 条件
 func (qb *QueryBuilder) WhereNull(column string) *QueryBuilder {
-	return qb.Where(column + " IS NULL")
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col + " IS NULL")
+	}
+	return qb
 }
 
 // WhereNotNull IS NOT NULL 条件
 func (qb *QueryBuilder) WhereNotNull(column string) *QueryBuilder {
-	return qb.Where(column + " IS NOT NULL")
+	if col, ok := qb.column(column); ok {
+		return qb.Where(col + " IS NOT NULL")
+	}
+	return qb
 }
@@ -0,0 +1,44 @@
+package grds
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderByGroupBy(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+	}{
+		{"order single", c.Table("users").OrderBy("age"), "SELECT * FROM `users` ORDER BY `age`"},
+		{"order direction", c.Table("users").OrderBy("age desc"), "SELECT * FROM `users` ORDER BY `age` DESC"},
+		{"order multiple", c.Table("users").OrderBy("a DESC, b ASC, users.c"), "SELECT * FROM `users` ORDER BY `a` DESC, `b` ASC, `users`.`c`"},
+		{"order raw", c.Table("users").OrderByRaw("RAND()"), "SELECT * FROM `users` ORDER BY RAND()"},
+		{"order raw args", c.Table("users").OrderByRaw("FIELD(status, ?, ?)", "paid", "pending"), "SELECT * FROM `users` ORDER BY FIELD(status, ?, ?)"},
+		{"group multiple", c.Table("users").Select("a, b").GroupBy("a, b"), "SELECT a, b FROM `users` GROUP BY `a`, `b`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := toSQL(t, tt.qb, SQLFind); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderByGroupByInvalid(t *testing.T) {
+	c := newTestClient(t)
+	for _, qb := range []*QueryBuilder{
+		c.Table("users").OrderBy("age; DROP TABLE users"),
+		c.Table("users").OrderBy("a, "),
+		c.Table("users").OrderBy("a DESC b"),
+		c.Table("users").GroupBy("DATE(created_at)"),
+		c.Table("users").AllowColumns("a").OrderBy("a, b"),
+	} {
+		if _, _, err := qb.ToSQL(SQLFind); !errors.Is(err, ErrInvalidIdentifier) && !errors.Is(err, ErrColumnNotAllowed) {
+			t.Errorf("expected identifier error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+// Package queue 基于 MySQL 表的任务队列
+//
+// 任务保存在 grds_jobs 表中，worker 使用 SELECT ... FOR UPDATE SKIP LOCKED 并发领取任务，
+// 领取后在可见性超时内未确认的任务会被重新领取；失败的任务按退避时间重试，
+// 超过最大尝试次数后进入死信状态。
+//
+//	q, err := queue.New(client, "emails", nil)
+//	q.Enqueue(ctx, Email{To: "a@example.com"}, &queue.EnqueueOptions{Delay: time.Minute})
+//
+//	w := q.NewWorker(func(ctx context.Context, job *queue.Job) error {
+//	    var email Email
+//	    if err := job.Decode(&email); err != nil {
+//	        return queue.Permanent(err)
+//	    }
+//	    return send(ctx, email)
+//	}, &queue.WorkerOptions{Concurrency: 4})
+//	err = w.Run(ctx) // ctx 取消后等待处理中的任务完成再返回
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nicexiaonie/grds"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultTable 默认队列表名
+const DefaultTable = "grds_jobs"
+
+// maxErrorLength last_error 保存的最大长度
+const maxErrorLength = 4096
+
+// ErrClaimLost 任务的领取已失效：可见性超时后被其他 worker 重新领取，或已被确认
+var ErrClaimLost = errors.New("queue: job claim lost")
+
+// Status 任务状态
+type Status int8
+
+const (
+	StatusPending Status = iota // 等待执行
+	StatusRunning               // 已被领取
+	StatusDone                  // 已完成
+	StatusDead                  // 超过最大尝试次数或永久失败（死信）
+)
+
+// String 返回状态名
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusRunning:
+		return "running"
+	case StatusDone:
+		return "done"
+	case StatusDead:
+		return "dead"
+	}
+	return fmt.Sprintf("status(%d)", int8(s))
+}
+
+// Job 队列任务
+type Job struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement"`
+	Queue       string     `gorm:"size:64;not null;index:idx_grds_jobs_claim,priority:1"`
+	Status      Status     `gorm:"type:tinyint;not null;default:0;index:idx_grds_jobs_claim,priority:2"`
+	Priority    int        `gorm:"not null;default:0"`                            // 越大越先执行
+	RunAt       time.Time  `gorm:"not null;index:idx_grds_jobs_claim,priority:3"` // 最早可执行时间
+	Payload     []byte     `gorm:"type:mediumblob"`                               // 任务数据
+	Attempts    int        `gorm:"not null;default:0"`                            // 已领取次数
+	MaxAttempts int        `gorm:"not null;default:0"`                            // 最大尝试次数
+	LastError   string     `gorm:"type:text"`                                     // 最近一次失败原因
+	LockedBy    string     `gorm:"size:64;not null;default:''"`                   // 领取令牌
+	LockedUntil *time.Time `gorm:"index"`                                         // 可见性超时时间
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName 默认表名
+func (Job) TableName() string {
+	return DefaultTable
+}
+
+// Decode 把 JSON 任务数据解析到 v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Options 队列配置
+type Options struct {
+	Table             string                          // 表名，默认 grds_jobs
+	VisibilityTimeout time.Duration                   // 可见性超时，领取后超过该时间未确认的任务会被重新领取，默认 30 秒
+	MaxAttempts       int                             // 默认最大尝试次数，默认 5
+	Backoff           func(attempt int) time.Duration // 第 attempt 次失败后的重试间隔，默认 ExponentialBackoff(time.Second, time.Hour)
+	SkipMigrate       bool                            // 不自动创建队列表
+}
+
+// DefaultOptions 创建默认配置
+func DefaultOptions() *Options {
+	return &Options{
+		Table:             DefaultTable,
+		VisibilityTimeout: 30 * time.Second,
+		MaxAttempts:       5,
+		Backoff:           ExponentialBackoff(time.Second, time.Hour),
+	}
+}
+
+// ExponentialBackoff 指数退避：base * 2^(attempt-1)，不超过 max
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// EnqueueOptions 入队选项
+type EnqueueOptions struct {
+	Priority    int           // 优先级，越大越先执行
+	Delay       time.Duration // 延迟执行
+	RunAt       time.Time     // 指定执行时间，优先于 Delay
+	MaxAttempts int           // 最大尝试次数，默认使用队列配置
+}
+
+// Queue 一个命名队列
+type Queue struct {
+	client *grds.Client
+	name   string
+	opts   Options
+}
+
+// New 创建队列，默认自动创建队列表
+func New(client *grds.Client, name string, opts *Options) (*Queue, error) {
+	if name == "" {
+		return nil, fmt.Errorf("queue: name is required")
+	}
+
+	o := *DefaultOptions()
+	if opts != nil {
+		if opts.Table != "" {
+			o.Table = opts.Table
+		}
+		if opts.VisibilityTimeout > 0 {
+			o.VisibilityTimeout = opts.VisibilityTimeout
+		}
+		if opts.MaxAttempts > 0 {
+			o.MaxAttempts = opts.MaxAttempts
+		}
+		if opts.Backoff != nil {
+			o.Backoff = opts.Backoff
+		}
+		o.SkipMigrate = opts.SkipMigrate
+	}
+	if !grds.ValidIdentifier(o.Table) {
+		return nil, fmt.Errorf("queue: %w: %q", grds.ErrInvalidIdentifier, o.Table)
+	}
+
+	q := &Queue{client: client, name: name, opts: o}
+	if !o.SkipMigrate {
+		if err := q.Migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// Name 队列名
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// Migrate 创建或更新队列表
+func (q *Queue) Migrate(ctx context.Context) error {
+	if err := q.client.DB().WithContext(ctx).Table(q.opts.Table).AutoMigrate(&Job{}); err != nil {
+		return fmt.Errorf("queue: migrate %s: %w", q.opts.Table, err)
+	}
+	return nil
+}
+
+// db 返回队列表的查询
+func (q *Queue) db(ctx context.Context) *gorm.DB {
+	return q.client.DB().WithContext(ctx).Table(q.opts.Table)
+}
+
+// Enqueue 添加任务，payload 为 []byte 或 string 时原样保存，其他类型编码为 JSON
+func (q *Queue) Enqueue(ctx context.Context, payload interface{}, opts *EnqueueOptions) (*Job, error) {
+	if opts == nil {
+		opts = &EnqueueOptions{}
+	}
+
+	var data []byte
+	switch v := payload.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("queue: encode payload: %w", err)
+		}
+		data = encoded
+	}
+
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now().Add(opts.Delay)
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.opts.MaxAttempts
+	}
+
+	job := &Job{
+		Queue:       q.name,
+		Status:      StatusPending,
+		Priority:    opts.Priority,
+		RunAt:       runAt,
+		Payload:     data,
+		MaxAttempts: maxAttempts,
+	}
+	if err := q.db(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Claim 领取最多 n 个可执行的任务
+//
+// 在事务中用 FOR UPDATE SKIP LOCKED 选出待执行或可见性超时的任务并标记为执行中，
+// 并发的 worker 互不阻塞。已用尽尝试次数的超时任务直接进入死信。
+func (q *Queue) Claim(ctx context.Context, n int) ([]*Job, error) {
+	if n <= 0 {
+		n = 1
+	}
+
+	var claimed []*Job
+	err := grds.NewTxManager(q.client.DB().WithContext(ctx)).Execute(func(tx *gorm.DB) error {
+		now := time.Now()
+		var jobs []*Job
+		err := tx.Table(q.opts.Table).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(clause.Eq{Column: clause.Column{Name: "queue"}, Value: q.name}).
+			Where(clause.Or(
+				clause.And(
+					clause.Eq{Column: clause.Column{Name: "status"}, Value: StatusPending},
+					clause.Lte{Column: clause.Column{Name: "run_at"}, Value: now},
+				),
+				clause.And(
+					clause.Eq{Column: clause.Column{Name: "status"}, Value: StatusRunning},
+					clause.Lte{Column: clause.Column{Name: "locked_until"}, Value: now},
+				),
+			)).
+			Order("priority DESC, run_at, id").
+			Limit(n).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		token, err := newToken()
+		if err != nil {
+			return err
+		}
+		until := now.Add(q.opts.VisibilityTimeout)
+
+		var ids, dead []uint64
+		for _, job := range jobs {
+			if job.Attempts >= job.MaxAttempts {
+				dead = append(dead, job.ID)
+				continue
+			}
+			ids = append(ids, job.ID)
+			job.Status = StatusRunning
+			job.Attempts++
+			job.LockedBy = token
+			job.LockedUntil = &until
+			job.UpdatedAt = now
+			claimed = append(claimed, job)
+		}
+
+		if len(dead) > 0 {
+			err := tx.Table(q.opts.Table).Where("id IN ?", dead).Updates(map[string]interface{}{
+				"status":       StatusDead,
+				"last_error":   "visibility timeout exceeded",
+				"locked_by":    "",
+				"locked_until": nil,
+				"updated_at":   now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(ids) > 0 {
+			return tx.Table(q.opts.Table).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":       StatusRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_by":    token,
+				"locked_until": until,
+				"updated_at":   now,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Ack 确认任务完成
+func (q *Queue) Ack(ctx context.Context, job *Job) error {
+	return q.release(ctx, job, map[string]interface{}{
+		"status": StatusDone,
+	})
+}
+
+// Nack 任务失败：未超过最大尝试次数时按退避时间重试，否则进入死信
+//
+// cause 由 Permanent 包装时不再重试，直接进入死信。
+func (q *Queue) Nack(ctx context.Context, job *Job, cause error) error {
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+	}
+
+	values := map[string]interface{}{"last_error": message}
+	var permanent *permanentError
+	if errors.As(cause, &permanent) || job.Attempts >= job.MaxAttempts {
+		values["status"] = StatusDead
+	} else {
+		values["status"] = StatusPending
+		values["run_at"] = time.Now().Add(q.opts.Backoff(job.Attempts))
+	}
+	return q.release(ctx, job, values)
+}
+
+// release 结束一次领取，令牌不匹配时返回 ErrClaimLost
+func (q *Queue) release(ctx context.Context, job *Job, values map[string]interface{}) error {
+	if job.LockedBy == "" {
+		return ErrClaimLost
+	}
+	now := time.Now()
+	values["locked_by"] = ""
+	values["locked_until"] = nil
+	values["updated_at"] = now
+
+	result := q.db(ctx).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimLost
+	}
+
+	job.Status = values["status"].(Status)
+	job.LockedBy = ""
+	job.LockedUntil = nil
+	job.UpdatedAt = now
+	if message, ok := values["last_error"].(string); ok {
+		job.LastError = message
+	}
+	if runAt, ok := values["run_at"].(time.Time); ok {
+		job.RunAt = runAt
+	}
+	return nil
+}
+
+// Extend 延长任务的可见性超时，用于执行时间较长的任务
+func (q *Queue) Extend(ctx context.Context, job *Job, d time.Duration) error {
+	if job.LockedBy == "" {
+		return ErrClaimLost
+	}
+	until := time.Now().Add(d)
+	result := q.db(ctx).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimLost
+	}
+	job.LockedUntil = &until
+	return nil
+}
+
+// DeadLetters 列出死信任务，按更新时间倒序
+func (q *Queue) DeadLetters(ctx context.Context, limit int) ([]*Job, error) {
+	var jobs []*Job
+	tx := q.db(ctx).Where("queue = ? AND status = ?", q.name, StatusDead).Order("updated_at DESC, id DESC")
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	return jobs, tx.Find(&jobs).Error
+}
+
+// Requeue 把死信任务重新放回队列，尝试次数清零
+func (q *Queue) Requeue(ctx context.Context, id uint64) error {
+	result := q.db(ctx).
+		Where("id = ? AND queue = ? AND status = ?", id, q.name, StatusDead).
+		Updates(map[string]interface{}{
+			"status":     StatusPending,
+			"attempts":   0,
+			"run_at":     time.Now(),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge 删除 before 之前完成的任务
+func (q *Queue) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := q.db(ctx).
+		Where("queue = ? AND status = ? AND updated_at < ?", q.name, StatusDone, before).
+		Delete(&Job{})
+	return result.RowsAffected, result.Error
+}
+
+// Counts 各状态的任务数
+func (q *Queue) Counts(ctx context.Context) (map[Status]int64, error) {
+	var rows []struct {
+		Status Status
+		Total  int64
+	}
+	err := q.db(ctx).Select("status, COUNT(*) AS total").
+		Where("queue = ?", q.name).Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[Status]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Total
+	}
+	return counts, nil
+}
+
+// permanentError 不再重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装不需要重试的错误，Nack 时任务直接进入死信
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// newToken 生成领取令牌
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("queue: generate claim token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
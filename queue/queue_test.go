@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nicexiaonie/grds"
+	"github.com/nicexiaonie/grds/internal/dbtest"
+)
+
+// newTestQueue 创建由 sqlmock 驱动的队列，不自动建表
+func newTestQueue(t *testing.T, opts *Options) (*Queue, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.Mock(t)
+	client, err := grds.NewClientFromDB(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.SkipMigrate = true
+	q, err := New(client, "emails", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, mock
+}
+
+// timeNear 匹配与 want 相差不超过一秒的时间参数
+type timeNear struct {
+	want time.Time
+}
+
+func (m timeNear) Match(v driver.Value) bool {
+	got, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	d := got.Sub(m.want)
+	return d > -time.Second && d < time.Second
+}
+
+const claimSQL = "SELECT * FROM `grds_jobs` WHERE `queue` = ? AND ((`status` = ? AND `run_at` <= ?) OR (`status` = ? AND `locked_until` <= ?)) " +
+	"ORDER BY priority DESC, run_at, id LIMIT 2 FOR UPDATE SKIP LOCKED"
+
+func TestClaim(t *testing.T) {
+	q, mock := newTestQueue(t, &Options{VisibilityTimeout: time.Minute})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimSQL).
+		WithArgs("emails", StatusPending, timeNear{now}, StatusRunning, timeNear{now}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "queue", "status", "attempts", "max_attempts"}).
+			AddRow(1, "emails", StatusPending, 0, 3).
+			AddRow(2, "emails", StatusRunning, 3, 3))
+	mock.ExpectExec("UPDATE `grds_jobs` SET `last_error`=?,`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=? WHERE id IN (?)").
+		WithArgs("visibility timeout exceeded", "", nil, StatusDead, timeNear{now}, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `grds_jobs` SET `attempts`=attempts + 1,`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=? WHERE id IN (?)").
+		WithArgs(sqlmock.AnyArg(), timeNear{now.Add(time.Minute)}, StatusRunning, timeNear{now}, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	jobs, err := q.Claim(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs, want 1", len(jobs))
+	}
+	job := jobs[0]
+	if job.ID != 1 || job.Status != StatusRunning || job.Attempts != 1 || len(job.LockedBy) != 32 || job.LockedUntil == nil {
+		t.Errorf("claimed job = %+v", job)
+	}
+}
+
+func TestClaimEmpty(t *testing.T) {
+	q, mock := newTestQueue(t, nil)
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	jobs, err := q.Claim(context.Background(), 2)
+	if err != nil || len(jobs) != 0 {
+		t.Errorf("Claim = %v, %v", jobs, err)
+	}
+}
+
+func TestClaimRollback(t *testing.T) {
+	q, mock := newTestQueue(t, nil)
+	failure := errors.New("deadlock")
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimSQL).WillReturnError(failure)
+	mock.ExpectRollback()
+
+	if _, err := q.Claim(context.Background(), 2); !errors.Is(err, failure) {
+		t.Errorf("Claim error = %v, want %v", err, failure)
+	}
+}
+
+const releaseSQL = "UPDATE `grds_jobs` SET %s WHERE id = ? AND status = ? AND locked_by = ?"
+
+func TestAckNack(t *testing.T) {
+	permanent := Permanent(errors.New("bad payload"))
+	tests := []struct {
+		name     string
+		attempts int
+		run      func(q *Queue, job *Job) error
+		sql      string
+		args     []driver.Value
+		affected int64
+		err      error
+		status   Status
+	}{
+		{"ack", 1, func(q *Queue, job *Job) error { return q.Ack(context.Background(), job) },
+			"`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=?",
+			[]driver.Value{"", nil, StatusDone, sqlmock.AnyArg()}, 1, nil, StatusDone},
+		{"ack lost", 1, func(q *Queue, job *Job) error { return q.Ack(context.Background(), job) },
+			"`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=?",
+			[]driver.Value{"", nil, StatusDone, sqlmock.AnyArg()}, 0, ErrClaimLost, StatusRunning},
+		{"nack retry", 2, func(q *Queue, job *Job) error { return q.Nack(context.Background(), job, errors.New("timeout")) },
+			"`last_error`=?,`locked_by`=?,`locked_until`=?,`run_at`=?,`status`=?,`updated_at`=?",
+			[]driver.Value{"timeout", "", nil, timeNear{time.Now().Add(2 * time.Minute)}, StatusPending, sqlmock.AnyArg()}, 1, nil, StatusPending},
+		{"nack exhausted", 3, func(q *Queue, job *Job) error { return q.Nack(context.Background(), job, errors.New("timeout")) },
+			"`last_error`=?,`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=?",
+			[]driver.Value{"timeout", "", nil, StatusDead, sqlmock.AnyArg()}, 1, nil, StatusDead},
+		{"nack permanent", 1, func(q *Queue, job *Job) error { return q.Nack(context.Background(), job, permanent) },
+			"`last_error`=?,`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=?",
+			[]driver.Value{"bad payload", "", nil, StatusDead, sqlmock.AnyArg()}, 1, nil, StatusDead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, mock := newTestQueue(t, &Options{Backoff: func(attempt int) time.Duration {
+				return time.Duration(attempt) * time.Minute
+			}})
+			job := &Job{ID: 7, Status: StatusRunning, Attempts: tt.attempts, MaxAttempts: 3, LockedBy: "token"}
+			mock.ExpectExec(fmt.Sprintf(releaseSQL, tt.sql)).
+				WithArgs(append(tt.args, 7, StatusRunning, "token")...).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			if err := tt.run(q, job); err != tt.err {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if job.Status != tt.status {
+				t.Errorf("status = %v, want %v", job.Status, tt.status)
+			}
+			if tt.err == nil && (job.LockedBy != "" || job.LockedUntil != nil) {
+				t.Errorf("claim not cleared: %+v", job)
+			}
+		})
+	}
+}
+
+func TestReleaseWithoutToken(t *testing.T) {
+	q, _ := newTestQueue(t, nil)
+	job := &Job{ID: 7, Status: StatusRunning}
+	if err := q.Ack(context.Background(), job); err != ErrClaimLost {
+		t.Errorf("Ack error = %v, want ErrClaimLost", err)
+	}
+	if err := q.Extend(context.Background(), job, time.Minute); err != ErrClaimLost {
+		t.Errorf("Extend error = %v, want ErrClaimLost", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Handler 任务处理函数，返回 nil 确认任务，返回错误时按退避重试
+type Handler func(ctx context.Context, job *Job) error
+
+// WorkerOptions worker 配置
+type WorkerOptions struct {
+	Concurrency     int             // 并发处理的任务数，默认 1
+	PollInterval    time.Duration   // 没有任务时的轮询间隔，默认 1 秒
+	ShutdownTimeout time.Duration   // 停止后等待处理中任务的时间，超时后取消任务的 context；0 表示一直等待
+	OnError         func(err error) // 领取、确认任务失败时的回调，可选
+}
+
+// Worker 循环领取并处理任务
+type Worker struct {
+	queue   *Queue
+	handler Handler
+	opts    WorkerOptions
+}
+
+// NewWorker 创建 worker
+func (q *Queue) NewWorker(handler Handler, opts *WorkerOptions) *Worker {
+	w := &Worker{queue: q, handler: handler}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Concurrency <= 0 {
+		w.opts.Concurrency = 1
+	}
+	if w.opts.PollInterval <= 0 {
+		w.opts.PollInterval = time.Second
+	}
+	return w
+}
+
+// Run 运行 worker，直到 ctx 取消
+//
+// ctx 取消后不再领取新任务，等待处理中的任务完成（最多 ShutdownTimeout）后返回 nil。
+// 任务处理函数收到的 context 与 ctx 无关，只在 ShutdownTimeout 超时后取消。
+func (w *Worker) Run(ctx context.Context) error {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, w.opts.Concurrency)
+	for i := 0; i < w.opts.Concurrency; i++ {
+		slots <- struct{}{}
+	}
+
+	for {
+		// 至少等到一个空闲槽位，再尽量多占用空闲槽位批量领取
+		select {
+		case <-ctx.Done():
+			w.shutdown(&wg, cancelJobs)
+			return nil
+		case <-slots:
+		}
+		free := 1
+	drain:
+		for free < w.opts.Concurrency {
+			select {
+			case <-slots:
+				free++
+			default:
+				break drain
+			}
+		}
+
+		jobs, err := w.queue.Claim(ctx, free)
+		for i := len(jobs); i < free; i++ {
+			slots <- struct{}{}
+		}
+		if err != nil && ctx.Err() == nil {
+			w.report(err)
+		}
+
+		for _, job := range jobs {
+			wg.Add(1)
+			go func(job *Job) {
+				defer func() {
+					slots <- struct{}{}
+					wg.Done()
+				}()
+				w.process(jobCtx, job)
+			}(job)
+		}
+
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.opts.PollInterval):
+			}
+		}
+	}
+}
+
+// shutdown 等待处理中的任务
+func (w *Worker) shutdown(wg *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	if w.opts.ShutdownTimeout <= 0 {
+		wg.Wait()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(w.opts.ShutdownTimeout):
+		cancelJobs()
+		<-done
+	}
+}
+
+// process 处理一个任务并确认结果
+func (w *Worker) process(ctx context.Context, job *Job) {
+	err := w.call(ctx, job)
+
+	// 确认不受 worker 停止影响
+	if err == nil {
+		err = w.queue.Ack(context.Background(), job)
+	} else {
+		err = w.queue.Nack(context.Background(), job, err)
+	}
+	if err != nil {
+		w.report(fmt.Errorf("queue: job %d: %w", job.ID, err))
+	}
+}
+
+// call 调用处理函数，panic 视为失败
+func (w *Worker) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.handler(ctx, job)
+}
+
+// report 报告错误
+func (w *Worker) report(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
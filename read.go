@@ -0,0 +1,94 @@
+package grds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+// queryExecutor 替换 gorm:query，依次处理查询缓存和并发查询去重
+type queryExecutor struct {
+	cache  *queryCache // 未配置缓存时为 nil
+	dedupe bool        // Config.DedupeReads
+	flight flightGroup
+}
+
+// query 执行查询：命中缓存时跳过数据库，去重时合并相同的并发查询
+func (qe *queryExecutor) query(db *gorm.DB) {
+	ttl, cached := db.Get(cacheTTLKey)
+	var tags []string
+	if cached = cached && qe.cache != nil; cached {
+		// 没有标签的结果不会被任何写入失效，不缓存
+		tags = cacheTags(db)
+		cached = len(tags) > 0
+	}
+	dedupe := qe.dedupe
+	if _, ok := db.Get(dedupeKey); ok {
+		dedupe = true
+	}
+	if !cached && !dedupe || db.Error != nil || db.DryRun || !shareable(db) {
+		callbacks.Query(db)
+		return
+	}
+
+	callbacks.BuildQuerySQL(db)
+	if db.Error != nil {
+		return
+	}
+	if _, locked := db.Statement.Clauses["FOR"]; locked {
+		callbacks.Query(db)
+		return
+	}
+
+	key := queryResultKey(db)
+	var epoch uint64
+	if cached {
+		if qe.cache.lookup(db, key) {
+			return
+		}
+		epoch = qe.cache.snapshot()
+	}
+
+	var set *resultSet
+	if dedupe {
+		set = qe.flight.query(db, key)
+	} else {
+		set = fetchResultSet(db)
+	}
+	if set == nil {
+		return
+	}
+	set.scan(db)
+
+	if cached {
+		qe.cache.store(db, key, set, ttl.(time.Duration), tags, epoch)
+	}
+}
+
+// registerQueryExecutor 用 queryExecutor 替换 gorm:query
+func registerQueryExecutor(db *gorm.DB, qe *queryExecutor) error {
+	return db.Callback().Query().Replace("gorm:query", qe.query)
+}
+
+// shareable 结果能否缓存或共享：不在事务中，且目标为指针
+func shareable(db *gorm.DB) bool {
+	if inTransaction(db) || db.Statement.Dest == nil {
+		return false
+	}
+	return reflect.ValueOf(db.Statement.Dest).Kind() == reflect.Ptr
+}
+
+// queryResultKey 由 SQL、参数和目标类型生成结果键，不含 SQL 注释
+func queryResultKey(db *gorm.DB) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\x00%s", db.Statement.Dest, stripQueryComment(db, db.Statement.SQL.String()))
+	for _, v := range db.Statement.Vars {
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+	return "grds:query:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,462 @@
+package grds
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// resultSet 查询返回的原始列和值
+//
+// 缓存和合并查询共享原始值而不是扫描后的结果，每个调用者按自己的 Dest 重新扫描，
+// 得到的结果与直接查询完全一致（包括指向零值的指针字段），且互不共享内存。
+type resultSet struct {
+	columns []resultColumn
+	rows    [][]driver.Value
+}
+
+// resultColumn 结果列及驱动提供的类型信息
+type resultColumn struct {
+	name         string
+	databaseType string
+	scanType     reflect.Type // 驱动未提供时为 nil
+	nullable     bool
+	nullableOK   bool
+}
+
+// fetchResultSet 执行 Statement 中已构建的查询并读取全部原始值，失败时记录错误并返回 nil
+func fetchResultSet(db *gorm.DB) *resultSet {
+	rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
+	if err != nil {
+		db.AddError(err)
+		return nil
+	}
+	defer rows.Close()
+
+	set, err := readResultSet(rows)
+	if err != nil {
+		db.AddError(err)
+		return nil
+	}
+	return set
+}
+
+// readResultSet 读取 rows 的列信息和原始值
+func readResultSet(rows *sql.Rows) (*resultSet, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &resultSet{columns: make([]resultColumn, len(names))}
+	for i, name := range names {
+		column := resultColumn{name: name}
+		if i < len(types) {
+			column.databaseType = types[i].DatabaseTypeName()
+			column.scanType = types[i].ScanType()
+			column.nullable, column.nullableOK = types[i].Nullable()
+		}
+		set.columns[i] = column
+	}
+
+	values := make([]interface{}, len(names))
+	ptrs := make([]interface{}, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		// 扫描到 *interface{} 得到驱动返回的值，[]byte 已被复制
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]driver.Value, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		set.rows = append(set.rows, row)
+	}
+	return set, rows.Err()
+}
+
+// scan 按 db 的 Dest 扫描结果集，与 gorm:query 使用相同的扫描逻辑
+func (rs *resultSet) scan(db *gorm.DB) {
+	rows, err := replayDB.QueryContext(db.Statement.Context, "", rs)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	defer func() {
+		db.AddError(rows.Close())
+	}()
+	gorm.Scan(rows, db, 0)
+}
+
+// ==================== 结果回放 ====================
+
+// replayDB 把 resultSet 回放为 *sql.Rows，由 database/sql 完成类型转换
+var replayDB = sql.OpenDB(replayDriver{})
+
+// replayDriver 只支持以 *resultSet 为唯一参数的查询
+type replayDriver struct{}
+
+func (d replayDriver) Open(string) (driver.Conn, error)             { return replayConn{}, nil }
+func (d replayDriver) Connect(context.Context) (driver.Conn, error) { return replayConn{}, nil }
+func (d replayDriver) Driver() driver.Driver                        { return d }
+
+// replayConn 回放连接
+type replayConn struct{}
+
+var errReplayUnsupported = errors.New("result replay only supports queries")
+
+func (replayConn) Prepare(string) (driver.Stmt, error) { return nil, errReplayUnsupported }
+func (replayConn) Close() error                        { return nil }
+func (replayConn) Begin() (driver.Tx, error)           { return nil, errReplayUnsupported }
+
+// CheckNamedValue 接受 *resultSet 参数，不做转换
+func (replayConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+// QueryContext 回放参数中的结果集
+func (replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) != 1 {
+		return nil, errReplayUnsupported
+	}
+	set, ok := args[0].Value.(*resultSet)
+	if !ok {
+		return nil, errReplayUnsupported
+	}
+	return &replayRows{set: set}, nil
+}
+
+// replayRows 回放的行，返回值的副本，resultSet 可以被并发回放
+type replayRows struct {
+	set *resultSet
+	pos int
+}
+
+func (r *replayRows) Columns() []string {
+	names := make([]string, len(r.set.columns))
+	for i, column := range r.set.columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+func (r *replayRows) Close() error { return nil }
+
+func (r *replayRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.set.rows) {
+		return io.EOF
+	}
+	for i, v := range r.set.rows[r.pos] {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		dest[i] = v
+	}
+	r.pos++
+	return nil
+}
+
+func (r *replayRows) ColumnTypeScanType(index int) reflect.Type {
+	if t := r.set.columns[index].scanType; t != nil {
+		return t
+	}
+	return anyType
+}
+
+func (r *replayRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.set.columns[index].databaseType
+}
+
+func (r *replayRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.set.columns[index].nullable, r.set.columns[index].nullableOK
+}
+
+// ==================== 序列化 ====================
+
+// anyType 驱动未提供扫描类型时 database/sql 使用的类型
+var anyType = reflect.TypeOf(new(interface{})).Elem()
+
+// scanTypes 可以序列化的列扫描类型，覆盖 MySQL 驱动返回的全部类型
+var scanTypes = func() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	for _, v := range []interface{}{
+		int8(0), int16(0), int32(0), int64(0),
+		uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), false, "", []byte(nil), sql.RawBytes(nil), time.Time{},
+		sql.NullBool{}, sql.NullFloat64{}, sql.NullInt32{}, sql.NullInt64{}, sql.NullString{}, sql.NullTime{},
+		new(interface{}),
+	} {
+		t := reflect.TypeOf(v)
+		types[t.String()] = t
+	}
+	types[anyType.String()] = anyType
+	return types
+}()
+
+// 值的类型标记
+const (
+	valueNil byte = iota
+	valueInt64
+	valueUint64
+	valueFloat64
+	valueBool
+	valueBytes
+	valueString
+	valueTime
+)
+
+// resultSetVersion 序列化格式版本，格式变化时旧的缓存条目解码失败，当作未命中
+const resultSetVersion = 1
+
+// encodeResultSet 序列化结果集，包含无法序列化的扫描类型或值时返回错误
+func encodeResultSet(rs *resultSet) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(resultSetVersion)
+	putUvarint(&buf, uint64(len(rs.columns)))
+	for _, column := range rs.columns {
+		scanType := ""
+		if column.scanType != nil {
+			scanType = column.scanType.String()
+			if scanTypes[scanType] != column.scanType {
+				return nil, fmt.Errorf("unsupported scan type %s", scanType)
+			}
+		}
+		putString(&buf, column.name)
+		putString(&buf, column.databaseType)
+		putString(&buf, scanType)
+		putBool(&buf, column.nullable)
+		putBool(&buf, column.nullableOK)
+	}
+
+	putUvarint(&buf, uint64(len(rs.rows)))
+	for _, row := range rs.rows {
+		for _, v := range row {
+			if err := putValue(&buf, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// putValue 写入一个驱动值
+func putValue(buf *bytes.Buffer, v driver.Value) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(valueNil)
+	case int64:
+		buf.WriteByte(valueInt64)
+		var b [binary.MaxVarintLen64]byte
+		buf.Write(b[:binary.PutVarint(b[:], v)])
+	case uint64:
+		buf.WriteByte(valueUint64)
+		putUvarint(buf, v)
+	case float64:
+		buf.WriteByte(valueFloat64)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+		buf.Write(b[:])
+	case bool:
+		buf.WriteByte(valueBool)
+		putBool(buf, v)
+	case []byte:
+		buf.WriteByte(valueBytes)
+		putUvarint(buf, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		buf.WriteByte(valueString)
+		putString(buf, v)
+	case time.Time:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(valueTime)
+		putUvarint(buf, uint64(len(data)))
+		buf.Write(data)
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func putString(buf *bytes.Buffer, s string) {
+	putUvarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func putBool(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+var errCorruptResultSet = errors.New("corrupt cached result set")
+
+// decodeResultSet 反序列化结果集，数据来自外部缓存，长度都按剩余字节校验
+func decodeResultSet(data []byte) (*resultSet, error) {
+	r := bytes.NewReader(data)
+	if version, err := r.ReadByte(); err != nil || version != resultSetVersion {
+		return nil, errCorruptResultSet
+	}
+
+	numColumns, err := readLength(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	set := &resultSet{columns: make([]resultColumn, numColumns)}
+	for i := range set.columns {
+		column := &set.columns[i]
+		var scanType string
+		if column.name, err = readString(r); err != nil {
+			return nil, err
+		}
+		if column.databaseType, err = readString(r); err != nil {
+			return nil, err
+		}
+		if scanType, err = readString(r); err != nil {
+			return nil, err
+		}
+		if scanType != "" {
+			if column.scanType = scanTypes[scanType]; column.scanType == nil {
+				return nil, errCorruptResultSet
+			}
+		}
+		if column.nullable, err = readBool(r); err != nil {
+			return nil, err
+		}
+		if column.nullableOK, err = readBool(r); err != nil {
+			return nil, err
+		}
+	}
+
+	// 每个值至少占一个字节
+	numRows, err := readLength(r, numColumns)
+	if err != nil {
+		return nil, err
+	}
+	set.rows = make([][]driver.Value, numRows)
+	for i := range set.rows {
+		row := make([]driver.Value, numColumns)
+		for j := range row {
+			if row[j], err = readValue(r); err != nil {
+				return nil, err
+			}
+		}
+		set.rows[i] = row
+	}
+	if r.Len() != 0 {
+		return nil, errCorruptResultSet
+	}
+	return set, nil
+}
+
+// readValue 读取一个驱动值
+func readValue(r *bytes.Reader) (driver.Value, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return nil, errCorruptResultSet
+	}
+	switch kind {
+	case valueNil:
+		return nil, nil
+	case valueInt64:
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, errCorruptResultSet
+		}
+		return v, nil
+	case valueUint64:
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, errCorruptResultSet
+		}
+		return v, nil
+	case valueFloat64:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, errCorruptResultSet
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b[:])), nil
+	case valueBool:
+		return readBool(r)
+	case valueBytes:
+		return readBytes(r)
+	case valueString:
+		return readString(r)
+	case valueTime:
+		data, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		var t time.Time
+		if err := t.UnmarshalBinary(data); err != nil {
+			return nil, errCorruptResultSet
+		}
+		return t, nil
+	default:
+		return nil, errCorruptResultSet
+	}
+}
+
+// readLength 读取长度，每个单位至少占 unit 个字节，超出剩余数据时视为损坏
+func readLength(r *bytes.Reader, unit int) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, errCorruptResultSet
+	}
+	if unit < 1 {
+		unit = 1
+	}
+	if n > uint64(r.Len()/unit) {
+		return 0, errCorruptResultSet
+	}
+	return int(n), nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readLength(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errCorruptResultSet
+	}
+	return b, nil
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	b, err := readBytes(r)
+	return string(b), err
+}
+
+func readBool(r *bytes.Reader) (bool, error) {
+	b, err := r.ReadByte()
+	if err != nil || b > 1 {
+		return false, errCorruptResultSet
+	}
+	return b == 1, nil
+}
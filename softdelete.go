@@ -0,0 +1,315 @@
+package grds
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SoftDeleteScheme 软删除方案
+type SoftDeleteScheme int
+
+const (
+	SoftDeleteTimestamp SoftDeleteScheme = iota // deleted_at DATETIME，NULL 表示未删除（与 gorm.DeletedAt 相同）
+	SoftDeleteFlag                              // is_deleted TINYINT，0 未删除，1 已删除
+	SoftDeleteUnix                              // deleted_at 保存 unix 时间戳，NULL 表示未删除
+	SoftDeleteUnixZero                          // deleted_at 保存 unix 时间戳，0 表示未删除，可与业务列组成唯一索引
+)
+
+// SoftDeletePolicy 软删除策略
+type SoftDeletePolicy struct {
+	Scheme SoftDeleteScheme
+	Column string // 列名，默认 SoftDeleteFlag 为 is_deleted，其余为 deleted_at
+}
+
+// SoftDeleter 模型实现该接口以使用 gorm.DeletedAt 以外的软删除方案
+//
+//	func (User) SoftDeletePolicy() grds.SoftDeletePolicy {
+//	    return grds.SoftDeletePolicy{Scheme: grds.SoftDeleteFlag}
+//	}
+type SoftDeleter interface {
+	SoftDeletePolicy() SoftDeletePolicy
+}
+
+var (
+	softDeleteMu     sync.RWMutex
+	softDeleteTables = map[string]SoftDeletePolicy{}
+)
+
+// RegisterSoftDelete 为表注册软删除策略，用于没有模型的 Table 查询
+func RegisterSoftDelete(table string, policy SoftDeletePolicy) {
+	softDeleteMu.Lock()
+	defer softDeleteMu.Unlock()
+	softDeleteTables[table] = policy
+}
+
+// UnregisterSoftDelete 移除表的软删除策略
+func UnregisterSoftDelete(table string) {
+	softDeleteMu.Lock()
+	defer softDeleteMu.Unlock()
+	delete(softDeleteTables, table)
+}
+
+// column 返回软删除列名
+func (p SoftDeletePolicy) column() string {
+	if p.Column != "" {
+		return p.Column
+	}
+	if p.Scheme == SoftDeleteFlag {
+		return "is_deleted"
+	}
+	return "deleted_at"
+}
+
+// aliveCondition 未删除条件
+func (p SoftDeletePolicy) aliveCondition() clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: p.column()}
+	switch p.Scheme {
+	case SoftDeleteFlag, SoftDeleteUnixZero:
+		return clause.Eq{Column: column, Value: 0}
+	}
+	return clause.Eq{Column: column, Value: nil}
+}
+
+// trashedCondition 已删除条件
+func (p SoftDeletePolicy) trashedCondition() clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: p.column()}
+	switch p.Scheme {
+	case SoftDeleteFlag, SoftDeleteUnixZero:
+		return clause.Neq{Column: column, Value: 0}
+	}
+	return clause.Neq{Column: column, Value: nil}
+}
+
+// deletedValue 删除时写入的值
+func (p SoftDeletePolicy) deletedValue(db *gorm.DB) interface{} {
+	switch p.Scheme {
+	case SoftDeleteFlag:
+		return 1
+	case SoftDeleteUnix, SoftDeleteUnixZero:
+		return db.NowFunc().Unix()
+	}
+	return db.NowFunc()
+}
+
+// aliveValue 恢复时写入的值
+func (p SoftDeletePolicy) aliveValue() interface{} {
+	switch p.Scheme {
+	case SoftDeleteFlag, SoftDeleteUnixZero:
+		return 0
+	}
+	return nil
+}
+
+// softDeleteOnlyTrashedKey OnlyTrashed 在 Statement.Settings 中的标记
+const softDeleteOnlyTrashedKey = "grds:only_trashed"
+
+// softDeleteEnabledKey 与 gorm 内置软删除共用的子句标记，
+// 防止重复添加条件，并让 gorm 在缺少其他条件时拒绝全表更新/删除
+const softDeleteEnabledKey = "soft_delete_enabled"
+
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// lookupSoftDelete 查找语句对应的软删除策略
+//
+// 优先使用模型的 SoftDeleter 实现，其次是按表名注册的策略；
+// withDeletedAt 为 true 时，gorm.DeletedAt 字段也视为 SoftDeleteTimestamp 策略。
+func lookupSoftDelete(s *schema.Schema, table string, tableExpr *clause.Expr, withDeletedAt bool) (SoftDeletePolicy, bool) {
+	if s != nil {
+		if sd, ok := reflect.New(s.ModelType).Interface().(SoftDeleter); ok {
+			return sd.SoftDeletePolicy(), true
+		}
+	}
+
+	softDeleteMu.RLock()
+	policy, ok := softDeleteTables[table]
+	if !ok && tableExpr != nil {
+		// Table("users u") 时 table 为别名，按表达式中的表名查找
+		if fields := strings.Fields(tableExpr.SQL); len(fields) > 0 {
+			policy, ok = softDeleteTables[strings.Trim(fields[0], "`")]
+		}
+	}
+	softDeleteMu.RUnlock()
+	if ok {
+		return policy, true
+	}
+
+	if withDeletedAt && s != nil {
+		for _, field := range s.Fields {
+			if field.FieldType == deletedAtType && field.DBName != "" {
+				return SoftDeletePolicy{Scheme: SoftDeleteTimestamp, Column: field.DBName}, true
+			}
+		}
+	}
+	return SoftDeletePolicy{}, false
+}
+
+// statementSoftDelete 返回语句要添加的软删除条件
+func statementSoftDelete(stmt *gorm.Statement) (SoftDeletePolicy, clause.Expression, bool) {
+	if _, ok := stmt.Clauses[softDeleteEnabledKey]; ok || stmt.SQL.Len() > 0 {
+		return SoftDeletePolicy{}, nil, false
+	}
+
+	if stmt.Unscoped {
+		if only, _ := stmt.Settings.Load(softDeleteOnlyTrashedKey); only != true {
+			return SoftDeletePolicy{}, nil, false
+		}
+		policy, ok := lookupSoftDelete(stmt.Schema, stmt.Table, stmt.TableExpr, true)
+		if !ok {
+			_ = stmt.AddError(fmt.Errorf("only trashed: table %q has no soft delete column", stmt.Table))
+			return SoftDeletePolicy{}, nil, false
+		}
+		return policy, policy.trashedCondition(), true
+	}
+
+	// gorm.DeletedAt 模型由 gorm 自身处理
+	policy, ok := lookupSoftDelete(stmt.Schema, stmt.Table, stmt.TableExpr, false)
+	if !ok {
+		return SoftDeletePolicy{}, nil, false
+	}
+	return policy, policy.aliveCondition(), true
+}
+
+// addSoftDeleteCondition 添加软删除条件
+func addSoftDeleteCondition(stmt *gorm.Statement, cond clause.Expression) {
+	groupWhereOr(stmt)
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{cond}})
+	stmt.Clauses[softDeleteEnabledKey] = clause.Clause{}
+}
+
+// groupWhereOr 已有条件中只有一个 Or 时先用 And 包起来，避免追加的条件与之组合成 OR
+func groupWhereOr(stmt *gorm.Statement) {
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, expr := range where.Exprs {
+				if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					c.Expression = where
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+}
+
+// softDeleteQuery 查询和更新时过滤已删除（或仅保留已删除）的记录
+func softDeleteQuery(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if _, cond, ok := statementSoftDelete(db.Statement); ok {
+		addSoftDeleteCondition(db.Statement, cond)
+	}
+}
+
+// softDeleteDelete 把删除改写为更新软删除列，Unscoped 时保持物理删除
+func softDeleteDelete(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Unscoped || stmt.SQL.Len() > 0 {
+		return
+	}
+	policy, cond, ok := statementSoftDelete(stmt)
+	if !ok {
+		return
+	}
+
+	column, value := policy.column(), policy.deletedValue(db)
+	stmt.AddClause(clause.Set{{Column: clause.Column{Name: column}, Value: value}})
+	if stmt.Schema != nil && stmt.Schema.LookUpField(column) != nil && stmt.ReflectValue.IsValid() {
+		stmt.SetColumn(column, value, true)
+	}
+
+	// 与 gorm:delete 相同，按主键值限定删除范围
+	if stmt.Schema != nil {
+		_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		}
+
+		if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+			_, queryValues = schema.GetIdentityFieldValuesMap(stmt.Context, reflect.ValueOf(stmt.Model), stmt.Schema.PrimaryFields)
+			column, values = schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+			if len(values) > 0 {
+				stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+			}
+		}
+	}
+
+	addSoftDeleteCondition(stmt, cond)
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.Build(db.Callback().Update().Clauses...)
+}
+
+// registerSoftDelete 注册软删除回调
+func registerSoftDelete(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("grds:soft_delete_query", softDeleteQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("grds:soft_delete_row", softDeleteQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("grds:soft_delete_update", softDeleteQuery); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("grds:soft_delete_delete", softDeleteDelete)
+}
+
+// ==================== 查询构建器 ====================
+
+// WithTrashed 包含已软删除的记录
+func (qb *QueryBuilder) WithTrashed() *QueryBuilder {
+	qb.db = qb.db.Unscoped()
+	return qb
+}
+
+// OnlyTrashed 只查询已软删除的记录
+func (qb *QueryBuilder) OnlyTrashed() *QueryBuilder {
+	qb.db = qb.db.Unscoped().Set(softDeleteOnlyTrashedKey, true)
+	return qb
+}
+
+// Restore 恢复已软删除的记录，需要带条件（或模型主键）
+//
+//	grds.Model(&User{}).WhereEq("id", 1).Restore()
+func (qb *QueryBuilder) Restore() error {
+	policy, err := qb.softDeletePolicy()
+	if err != nil {
+		return newError("restore", qb.db, err)
+	}
+	return wrapError("restore", qb.db.Unscoped().Set(softDeleteOnlyTrashedKey, true).
+		UpdateColumn(policy.column(), policy.aliveValue()))
+}
+
+// ForceDelete 物理删除记录，忽略软删除
+func (qb *QueryBuilder) ForceDelete(value interface{}, conds ...interface{}) error {
+	return wrapError("force_delete", qb.db.Unscoped().Delete(value, conds...))
+}
+
+// softDeletePolicy 解析当前模型或表的软删除策略
+func (qb *QueryBuilder) softDeletePolicy() (SoftDeletePolicy, error) {
+	stmt := qb.db.Statement
+	s, table := stmt.Schema, stmt.Table
+	if stmt.Model != nil {
+		parsed := &gorm.Statement{DB: qb.db}
+		if err := parsed.Parse(stmt.Model); err != nil {
+			return SoftDeletePolicy{}, err
+		}
+		s = parsed.Schema
+		if table == "" {
+			table = parsed.Table
+		}
+	}
+
+	policy, ok := lookupSoftDelete(s, table, stmt.TableExpr, true)
+	if !ok {
+		return SoftDeletePolicy{}, fmt.Errorf("table %q has no soft delete column", table)
+	}
+	return policy, nil
+}
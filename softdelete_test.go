@@ -0,0 +1,129 @@
+package grds
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 软删除测试中对查询构建器执行的操作
+var (
+	sdFind = func(qb *QueryBuilder) error {
+		var rows []map[string]interface{}
+		return qb.Find(&rows)
+	}
+	sdCount = func(qb *QueryBuilder) error {
+		_, err := qb.Count()
+		return err
+	}
+	sdUpdate = func(qb *QueryBuilder) error {
+		return qb.Update("name", "b")
+	}
+	sdDelete = func(qb *QueryBuilder) error {
+		if model := qb.DB().Statement.Model; model != nil {
+			return qb.Delete(model)
+		}
+		return qb.Delete(map[string]interface{}{})
+	}
+	sdRestore = func(qb *QueryBuilder) error {
+		return qb.Restore()
+	}
+)
+
+type flagDeletedItem struct {
+	ID        int64
+	Name      string
+	IsDeleted int
+}
+
+func (flagDeletedItem) SoftDeletePolicy() SoftDeletePolicy {
+	return SoftDeletePolicy{Scheme: SoftDeleteFlag}
+}
+
+type unixZeroItem struct {
+	ID        int64
+	DeletedAt int64
+}
+
+func (unixZeroItem) SoftDeletePolicy() SoftDeletePolicy {
+	return SoftDeletePolicy{Scheme: SoftDeleteUnixZero}
+}
+
+func TestSoftDeleteSQL(t *testing.T) {
+	c := newTestClient(t)
+	RegisterSoftDelete("sd_posts", SoftDeletePolicy{Scheme: SoftDeleteTimestamp})
+	RegisterSoftDelete("sd_tags", SoftDeletePolicy{Scheme: SoftDeleteUnix, Column: "removed_at"})
+	defer UnregisterSoftDelete("sd_posts")
+	defer UnregisterSoftDelete("sd_tags")
+
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		run  func(*QueryBuilder) error
+		want string
+	}{
+		{"flag find", c.Model(&flagDeletedItem{}).WhereEq("name", "a"), sdFind,
+			"SELECT * FROM `flag_deleted_items` WHERE `name` = ? AND `flag_deleted_items`.`is_deleted` = ?"},
+		{"flag count", c.Model(&flagDeletedItem{}), sdCount,
+			"SELECT count(*) FROM `flag_deleted_items` WHERE `flag_deleted_items`.`is_deleted` = ?"},
+		{"flag update", c.Model(&flagDeletedItem{}).WhereEq("id", 1), sdUpdate,
+			"UPDATE `flag_deleted_items` SET `name`=? WHERE `id` = ? AND `flag_deleted_items`.`is_deleted` = ?"},
+		{"flag delete", c.Model(&flagDeletedItem{ID: 1}), sdDelete,
+			"UPDATE `flag_deleted_items` SET `is_deleted`=? WHERE `flag_deleted_items`.`id` = ? AND `flag_deleted_items`.`is_deleted` = ?"},
+		{"flag with trashed", c.Model(&flagDeletedItem{}).WithTrashed(), sdFind,
+			"SELECT * FROM `flag_deleted_items`"},
+		{"flag only trashed", c.Model(&flagDeletedItem{}).OnlyTrashed(), sdFind,
+			"SELECT * FROM `flag_deleted_items` WHERE `flag_deleted_items`.`is_deleted` <> ?"},
+		{"flag force delete", c.Model(&flagDeletedItem{ID: 1}).WithTrashed(), sdDelete,
+			"DELETE FROM `flag_deleted_items` WHERE `flag_deleted_items`.`id` = ?"},
+		{"unix zero find", c.Model(&unixZeroItem{}), sdFind,
+			"SELECT * FROM `unix_zero_items` WHERE `unix_zero_items`.`deleted_at` = ?"},
+		{"registered table", c.Table("sd_posts").WhereEq("id", 1), sdFind,
+			"SELECT * FROM `sd_posts` WHERE `id` = ? AND `sd_posts`.`deleted_at` IS NULL"},
+		{"registered alias", c.Table("sd_posts p").WhereEq("p.id", 1), sdFind,
+			"SELECT * FROM sd_posts p WHERE `p`.`id` = ? AND `p`.`deleted_at` IS NULL"},
+		{"registered or grouped", c.Table("sd_posts").Or("id = ?", 1), sdFind,
+			"SELECT * FROM `sd_posts` WHERE id = ? AND `sd_posts`.`deleted_at` IS NULL"},
+		{"registered delete", c.Table("sd_tags").WhereEq("id", 1), sdDelete,
+			"UPDATE `sd_tags` SET `removed_at`=? WHERE `id` = ? AND `sd_tags`.`removed_at` IS NULL"},
+		{"registered only trashed", c.Table("sd_tags").OnlyTrashed(), sdFind,
+			"SELECT * FROM `sd_tags` WHERE `sd_tags`.`removed_at` IS NOT NULL"},
+		{"flag restore", c.Model(&flagDeletedItem{}).WhereEq("id", 1), sdRestore,
+			"UPDATE `flag_deleted_items` SET `is_deleted`=? WHERE `id` = ? AND `flag_deleted_items`.`is_deleted` <> ?"},
+		{"registered restore", c.Table("sd_tags").WhereEq("id", 1), sdRestore,
+			"UPDATE `sd_tags` SET `removed_at`=? WHERE `id` = ? AND `sd_tags`.`removed_at` IS NOT NULL"},
+		{"unregistered table", c.Table("sd_other").WhereEq("id", 1), sdDelete,
+			"DELETE FROM `sd_other` WHERE `id` = ?"},
+	}
+	for _, tt := range tests {
+		if got, _ := c.lastSQL(t, tt.run(tt.qb)); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSoftDeleteValues(t *testing.T) {
+	c := newTestClient(t)
+	_, vars := c.lastSQL(t, sdDelete(c.Model(&flagDeletedItem{ID: 1})))
+	if len(vars) != 3 || vars[0] != 1 || vars[2] != 0 {
+		t.Errorf("flag delete vars = %v, want [1 1 0]", vars)
+	}
+	_, vars = c.lastSQL(t, sdDelete(c.Model(&unixZeroItem{ID: 1})))
+	if unix, ok := vars[0].(int64); !ok || unix <= 0 {
+		t.Errorf("unix delete value = %#v", vars[0])
+	}
+}
+
+func TestOnlyTrashedWithoutColumn(t *testing.T) {
+	c := newTestClient(t)
+	err := sdFind(c.Table("sd_other").OnlyTrashed())
+	if err == nil || !strings.Contains(err.Error(), "no soft delete column") {
+		t.Errorf("error = %v", err)
+	}
+
+	err = c.Table("sd_other").WhereEq("id", 1).Restore()
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "restore" || e.Table != "sd_other" || !strings.Contains(err.Error(), "no soft delete column") {
+		t.Errorf("restore error = %#v", err)
+	}
+}
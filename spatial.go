@@ -0,0 +1,314 @@
+package grds
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SRIDWGS84 WGS 84 经纬度坐标系
+const SRIDWGS84 = 4326
+
+// WKB 几何类型
+const (
+	wkbPoint   = 1
+	wkbPolygon = 3
+)
+
+// ErrInvalidGeometry 无法解析的几何数据
+var ErrInvalidGeometry = errors.New("invalid geometry")
+
+// Point MySQL POINT，X 为经度，Y 为纬度
+//
+// 从数据库读取时解析 MySQL 内部格式（4 字节 SRID + WKB）；写入时生成
+// ST_GeomFromText(?, srid, 'axis-order=long-lat')，不受坐标系轴顺序影响。
+// 可为 NULL 的列使用 *Point。
+type Point struct {
+	X    float64 // 经度
+	Y    float64 // 纬度
+	SRID uint32
+}
+
+// NewPoint 创建 WGS 84 坐标点
+func NewPoint(lng, lat float64) Point {
+	return Point{X: lng, Y: lat, SRID: SRIDWGS84}
+}
+
+// Scan 实现 sql.Scanner
+func (p *Point) Scan(value interface{}) error {
+	if value == nil {
+		*p = Point{}
+		return nil
+	}
+	srid, r, err := geometryReader(value, wkbPoint)
+	if err != nil {
+		return err
+	}
+	x, y, err := r.point()
+	if err != nil {
+		return err
+	}
+	*p = Point{X: x, Y: y, SRID: srid}
+	return nil
+}
+
+// Value 实现 driver.Valuer，返回 MySQL 内部格式
+func (p Point) Value() (driver.Value, error) {
+	w := newGeometryWriter(p.SRID, wkbPoint)
+	w.point(p.X, p.Y)
+	return w.bytes(), nil
+}
+
+// GormValue 写入时使用 ST_GeomFromText
+func (p Point) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return geomFromText(p.WKT(), p.SRID)
+}
+
+// WKT 返回 POINT(x y)
+func (p Point) WKT() string {
+	return "POINT(" + formatCoord(p.X, p.Y) + ")"
+}
+
+// Polygon MySQL POLYGON，第一个环为外环，其余为内环（洞），每个环首尾点相同
+type Polygon struct {
+	Rings [][]Point // 环中点的 SRID 被忽略
+	SRID  uint32
+}
+
+// NewPolygon 创建 WGS 84 多边形，只有外环；首尾点不同时自动闭合
+func NewPolygon(points ...Point) Polygon {
+	ring := append([]Point{}, points...)
+	if n := len(ring); n > 0 && (ring[0].X != ring[n-1].X || ring[0].Y != ring[n-1].Y) {
+		ring = append(ring, ring[0])
+	}
+	return Polygon{Rings: [][]Point{ring}, SRID: SRIDWGS84}
+}
+
+// Scan 实现 sql.Scanner
+func (p *Polygon) Scan(value interface{}) error {
+	if value == nil {
+		*p = Polygon{}
+		return nil
+	}
+	srid, r, err := geometryReader(value, wkbPolygon)
+	if err != nil {
+		return err
+	}
+	numRings, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	// 每个环至少有 4 字节的点数，先校验再按声明的数量分配
+	if int(numRings) > r.remaining()/4 {
+		return fmt.Errorf("%w: polygon has %d rings", ErrInvalidGeometry, numRings)
+	}
+	rings := make([][]Point, 0, numRings)
+	for i := uint32(0); i < numRings; i++ {
+		numPoints, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		if int(numPoints) > r.remaining()/16 {
+			return fmt.Errorf("%w: ring has %d points", ErrInvalidGeometry, numPoints)
+		}
+		ring := make([]Point, numPoints)
+		for j := range ring {
+			if ring[j].X, ring[j].Y, err = r.point(); err != nil {
+				return err
+			}
+		}
+		rings = append(rings, ring)
+	}
+	*p = Polygon{Rings: rings, SRID: srid}
+	return nil
+}
+
+// Value 实现 driver.Valuer，返回 MySQL 内部格式
+func (p Polygon) Value() (driver.Value, error) {
+	w := newGeometryWriter(p.SRID, wkbPolygon)
+	w.uint32(uint32(len(p.Rings)))
+	for _, ring := range p.Rings {
+		w.uint32(uint32(len(ring)))
+		for _, pt := range ring {
+			w.point(pt.X, pt.Y)
+		}
+	}
+	return w.bytes(), nil
+}
+
+// GormValue 写入时使用 ST_GeomFromText
+func (p Polygon) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return geomFromText(p.WKT(), p.SRID)
+}
+
+// WKT 返回 POLYGON((x y, ...), ...)
+func (p Polygon) WKT() string {
+	var b strings.Builder
+	b.WriteString("POLYGON(")
+	for i, ring := range p.Rings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, pt := range ring {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(formatCoord(pt.X, pt.Y))
+		}
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// WhereWithinDistance 与 point 的球面距离不超过 meters 米（ST_Distance_Sphere）
+//
+//	grds.Table("shops").WhereWithinDistance("location", grds.NewPoint(121.47, 31.23), 1000)
+func (qb *QueryBuilder) WhereWithinDistance(column string, point Point, meters float64) *QueryBuilder {
+	if col, ok := qb.column(column); ok {
+		return qb.Where("ST_Distance_Sphere("+col+", ?) <= ?", point, meters)
+	}
+	return qb
+}
+
+// OrderByDistance 按与 point 的球面距离由近到远排序
+func (qb *QueryBuilder) OrderByDistance(column string, point Point) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok {
+		return qb
+	}
+	// 坐标由数字格式化生成，可以直接拼接
+	expr := "ST_Distance_Sphere(" + col + ", " + geomFromTextSQL("'"+point.WKT()+"'", point.SRID) + ")"
+	qb.db = qb.db.Order(clause.OrderByColumn{Column: clause.Column{Name: expr, Raw: true}})
+	return qb
+}
+
+// geomFromText 生成 ST_GeomFromText 表达式
+func geomFromText(wkt string, srid uint32) clause.Expr {
+	return clause.Expr{SQL: geomFromTextSQL("?", srid), Vars: []interface{}{wkt}}
+}
+
+// geomFromTextSQL 地理坐标系按经度、纬度的顺序解析
+func geomFromTextSQL(arg string, srid uint32) string {
+	if srid == 0 {
+		return "ST_GeomFromText(" + arg + ")"
+	}
+	return "ST_GeomFromText(" + arg + ", " + strconv.FormatUint(uint64(srid), 10) + ", 'axis-order=long-lat')"
+}
+
+// formatCoord 格式化坐标
+func formatCoord(x, y float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64) + " " + strconv.FormatFloat(y, 'f', -1, 64)
+}
+
+// ==================== MySQL 内部格式 ====================
+
+// wkbReader 读取 WKB
+type wkbReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// geometryReader 解析 SRID 和 WKB 头，校验几何类型
+func geometryReader(value interface{}, want uint32) (uint32, *wkbReader, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return 0, nil, fmt.Errorf("%w: unsupported type %T", ErrInvalidGeometry, value)
+	}
+	if len(data) < 9 {
+		return 0, nil, fmt.Errorf("%w: %d bytes", ErrInvalidGeometry, len(data))
+	}
+
+	srid := binary.LittleEndian.Uint32(data[:4])
+	r := &wkbReader{data: data[5:]}
+	switch data[4] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return 0, nil, fmt.Errorf("%w: byte order %d", ErrInvalidGeometry, data[4])
+	}
+	typ, err := r.uint32()
+	if err != nil {
+		return 0, nil, err
+	}
+	if typ != want {
+		return 0, nil, fmt.Errorf("%w: geometry type %d, want %d", ErrInvalidGeometry, typ, want)
+	}
+	return srid, r, nil
+}
+
+// uint32 读取 4 字节整数
+func (r *wkbReader) uint32() (uint32, error) {
+	if len(r.data) < 4 {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidGeometry)
+	}
+	v := r.order.Uint32(r.data)
+	r.data = r.data[4:]
+	return v, nil
+}
+
+// point 读取两个 float64 坐标
+func (r *wkbReader) point() (float64, float64, error) {
+	if len(r.data) < 16 {
+		return 0, 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidGeometry)
+	}
+	x := math.Float64frombits(r.order.Uint64(r.data))
+	y := math.Float64frombits(r.order.Uint64(r.data[8:]))
+	r.data = r.data[16:]
+	return x, y, nil
+}
+
+// remaining 剩余字节数
+func (r *wkbReader) remaining() int {
+	return len(r.data)
+}
+
+// wkbWriter 生成小端序的 MySQL 内部格式
+type wkbWriter struct {
+	buf []byte
+}
+
+// newGeometryWriter 写入 SRID 和 WKB 头
+func newGeometryWriter(srid, typ uint32) *wkbWriter {
+	w := &wkbWriter{buf: make([]byte, 0, 64)}
+	w.uint32(srid)
+	w.buf = append(w.buf, 1)
+	w.uint32(typ)
+	return w
+}
+
+// uint32 写入 4 字节整数
+func (w *wkbWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.buf = append(w.buf, b[:]...)
+}
+
+// point 写入两个 float64 坐标
+func (w *wkbWriter) point(x, y float64) {
+	var b [16]byte
+	binary.LittleEndian.PutUint64(b[:8], math.Float64bits(x))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(y))
+	w.buf = append(w.buf, b[:]...)
+}
+
+// bytes 返回结果
+func (w *wkbWriter) bytes() []byte {
+	return w.buf
+}
@@ -0,0 +1,113 @@
+package grds
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPointValueScan(t *testing.T) {
+	p := NewPoint(121.47, 31.23)
+	value, err := p.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SRID 4326 + 小端序 + 类型 1 + X + Y
+	want := "e6100000" + "01" + "01000000" + "ae47e17a145e5e40" + "7b14ae47e13a3f40"
+	if got := hex.EncodeToString(value.([]byte)); got != want {
+		t.Errorf("Value() = %s, want %s", got, want)
+	}
+
+	var got Point
+	if err := got.Scan(value); err != nil || got != p {
+		t.Errorf("Scan = %+v, %v, want %+v", got, err, p)
+	}
+	if err := got.Scan(string(value.([]byte))); err != nil || got != p {
+		t.Errorf("Scan(string) = %+v, %v, want %+v", got, err, p)
+	}
+	if err := got.Scan(nil); err != nil || got != (Point{}) {
+		t.Errorf("Scan(nil) = %+v, %v", got, err)
+	}
+}
+
+func TestPointScanBigEndian(t *testing.T) {
+	data, _ := hex.DecodeString("00000000" + "00" + "00000001" + "3ff0000000000000" + "4000000000000000")
+	var p Point
+	if err := p.Scan(data); err != nil || p != (Point{X: 1, Y: 2}) {
+		t.Errorf("Scan = %+v, %v", p, err)
+	}
+}
+
+func TestPolygonValueScan(t *testing.T) {
+	p := NewPolygon(NewPoint(0, 0), NewPoint(1, 0), NewPoint(1, 1))
+	if n := len(p.Rings[0]); n != 4 {
+		t.Fatalf("ring not closed: %d points", n)
+	}
+	value, err := p.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Polygon
+	if err := got.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	// Scan 不设置环中点的 SRID
+	want := Polygon{Rings: [][]Point{{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 0, 0}}}, SRID: SRIDWGS84}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan = %+v, want %+v", got, want)
+	}
+	if wkt := p.WKT(); wkt != "POLYGON((0 0, 1 0, 1 1, 0 0))" {
+		t.Errorf("WKT() = %s", wkt)
+	}
+}
+
+func TestGeometryScanInvalid(t *testing.T) {
+	point, _ := NewPoint(1, 2).Value()
+	polygon, _ := NewPolygon(NewPoint(0, 0), NewPoint(1, 0), NewPoint(1, 1)).Value()
+	hugeRing := append([]byte{}, polygon.([]byte)[:13]...)
+	hugeRing = append(hugeRing, 0xff, 0xff, 0xff, 0x7f)
+	// 声明大量的环但没有数据，不能按声明的数量分配
+	manyRings := append([]byte{}, polygon.([]byte)[:9]...)
+	manyRings = append(manyRings, 0xff, 0xff, 0xff, 0x7f)
+
+	tests := []struct {
+		name  string
+		dest  interface{ Scan(interface{}) error }
+		value interface{}
+	}{
+		{"unsupported type", &Point{}, 42},
+		{"too short", &Point{}, []byte{1, 2, 3}},
+		{"byte order", &Point{}, append(append([]byte{}, point.([]byte)[:4]...), append([]byte{2}, point.([]byte)[5:]...)...)},
+		{"wrong type", &Polygon{}, point},
+		{"truncated point", &Point{}, point.([]byte)[:20]},
+		{"ring too large", &Polygon{}, hugeRing},
+		{"too many rings", &Polygon{}, manyRings},
+		{"truncated ring", &Polygon{}, polygon.([]byte)[:15]},
+	}
+	for _, tt := range tests {
+		if err := tt.dest.Scan(tt.value); !errors.Is(err, ErrInvalidGeometry) {
+			t.Errorf("%s: error = %v, want ErrInvalidGeometry", tt.name, err)
+		}
+	}
+}
+
+func TestSpatialSQL(t *testing.T) {
+	c := newTestClient(t)
+	p := NewPoint(121.47, 31.23)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+	}{
+		{"within", c.Table("shops").WhereWithinDistance("location", p, 1000),
+			"SELECT * FROM `shops` WHERE ST_Distance_Sphere(`location`, ST_GeomFromText(?, 4326, 'axis-order=long-lat')) <= ?"},
+		{"order", c.Table("shops").OrderByDistance("location", p),
+			"SELECT * FROM `shops` ORDER BY ST_Distance_Sphere(`location`, ST_GeomFromText('POINT(121.47 31.23)', 4326, 'axis-order=long-lat'))"},
+	}
+	for _, tt := range tests {
+		if got, _ := toSQL(t, tt.qb, SQLFind); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package grds
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// subDB 校验子查询，出错时记录到当前查询上
+func (qb *QueryBuilder) subDB(sub *QueryBuilder) (*gorm.DB, bool) {
+	if sub == nil {
+		_ = qb.db.AddError(fmt.Errorf("subquery is nil"))
+		return nil, false
+	}
+	if sub.db.Error != nil {
+		_ = qb.db.AddError(sub.db.Error)
+		return nil, false
+	}
+	return sub.db, true
+}
+
+// WhereInSub IN 子查询条件：column IN (SELECT ...)
+func (qb *QueryBuilder) WhereInSub(column string, sub *QueryBuilder) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok {
+		return qb
+	}
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Where(col+" IN (?)", db)
+	}
+	return qb
+}
+
+// WhereNotInSub NOT IN 子查询条件
+func (qb *QueryBuilder) WhereNotInSub(column string, sub *QueryBuilder) *QueryBuilder {
+	col, ok := qb.column(column)
+	if !ok {
+		return qb
+	}
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Where(col+" NOT IN (?)", db)
+	}
+	return qb
+}
+
+// WhereExists EXISTS 子查询条件，关联条件写在子查询的 Where 中
+//
+//	grds.Table("users u").WhereExists(
+//	    grds.Table("orders o").Select("1").Where("o.user_id = u.id"))
+func (qb *QueryBuilder) WhereExists(sub *QueryBuilder) *QueryBuilder {
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Where("EXISTS (?)", db)
+	}
+	return qb
+}
+
+// WhereNotExists NOT EXISTS 子查询条件
+func (qb *QueryBuilder) WhereNotExists(sub *QueryBuilder) *QueryBuilder {
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Where("NOT EXISTS (?)", db)
+	}
+	return qb
+}
+
+// FromSub 以子查询作为数据源：FROM (SELECT ...) AS alias
+func (qb *QueryBuilder) FromSub(sub *QueryBuilder, alias string) *QueryBuilder {
+	quoted, err := QuoteIdentifier(alias)
+	if err != nil {
+		_ = qb.db.AddError(err)
+		return qb
+	}
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Table("(?) AS "+quoted, db)
+	}
+	return qb
+}
+
+// JoinSub 连接子查询：JOIN (SELECT ...) AS alias ON condition
+//
+// condition 按原样拼接，不能包含外部输入。
+func (qb *QueryBuilder) JoinSub(sub *QueryBuilder, alias string, condition string) *QueryBuilder {
+	return qb.joinSub("JOIN", sub, alias, condition)
+}
+
+// LeftJoinSub 左连接子查询
+func (qb *QueryBuilder) LeftJoinSub(sub *QueryBuilder, alias string, condition string) *QueryBuilder {
+	return qb.joinSub("LEFT JOIN", sub, alias, condition)
+}
+
+// joinSub 添加子查询连接
+func (qb *QueryBuilder) joinSub(kind string, sub *QueryBuilder, alias string, condition string) *QueryBuilder {
+	quoted, err := QuoteIdentifier(alias)
+	if err != nil {
+		_ = qb.db.AddError(err)
+		return qb
+	}
+	if db, ok := qb.subDB(sub); ok {
+		qb.db = qb.db.Joins(kind+" (?) AS "+quoted+" ON "+condition, db)
+	}
+	return qb
+}
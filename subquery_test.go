@@ -0,0 +1,80 @@
+package grds
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubquerySQL(t *testing.T) {
+	c := newTestClient(t)
+	paid := func() *QueryBuilder {
+		return c.Table("orders").Select("user_id").WhereEq("status", 1)
+	}
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+		vars []interface{}
+	}{
+		{"in", c.Table("users").WhereInSub("id", paid()),
+			"SELECT * FROM `users` WHERE `id` IN (SELECT user_id FROM `orders` WHERE `status` = ?)", []interface{}{1}},
+		{"not in", c.Table("users").WhereEq("role", "vip").WhereNotInSub("users.id", paid()),
+			"SELECT * FROM `users` WHERE `role` = ? AND `users`.`id` NOT IN (SELECT user_id FROM `orders` WHERE `status` = ?)",
+			[]interface{}{"vip", 1}},
+		{"exists", c.Table("users u").WhereExists(c.Table("orders o").Select("1").Where("o.user_id = u.id")),
+			"SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)", nil},
+		{"not exists", c.Table("users u").WhereNotExists(c.Table("orders o").Select("1").Where("o.user_id = u.id")),
+			"SELECT * FROM users u WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)", nil},
+		{"from", c.Table("users").FromSub(paid(), "t").WhereGt("t.user_id", 10),
+			"SELECT * FROM (SELECT user_id FROM `orders` WHERE `status` = ?) AS `t` WHERE `t`.`user_id` > ?", []interface{}{1, 10}},
+		{"join", c.Table("users u").JoinSub(paid(), "p", "p.user_id = u.id"),
+			"SELECT * FROM users u JOIN (SELECT user_id FROM `orders` WHERE `status` = ?) AS `p` ON p.user_id = u.id", []interface{}{1}},
+		{"left join", c.Table("users u").LeftJoinSub(paid(), "p", "p.user_id = u.id").WhereEq("u.status", 2),
+			"SELECT * FROM users u LEFT JOIN (SELECT user_id FROM `orders` WHERE `status` = ?) AS `p` ON p.user_id = u.id WHERE `u`.`status` = ?",
+			[]interface{}{1, 2}},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		sql, vars := c.lastSQL(t, tt.qb.Find(&rows))
+		if sql != tt.want || !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("%s:\n got %q %v\nwant %q %v", tt.name, sql, vars, tt.want, tt.vars)
+		}
+	}
+}
+
+func TestSubqueryInvalid(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		err  error
+	}{
+		{"nil subquery", c.Table("users").WhereExists(nil), nil},
+		{"failed subquery", c.Table("users").WhereInSub("id", c.Table("orders").WhereEq("x;", 1)), ErrInvalidIdentifier},
+		{"invalid column", c.Table("users").WhereInSub("id)", c.Table("orders")), ErrInvalidIdentifier},
+		{"invalid from alias", c.Table("users").FromSub(c.Table("orders"), "t x"), ErrInvalidIdentifier},
+		{"invalid join alias", c.Table("users").JoinSub(c.Table("orders"), "p`", "1 = 1"), ErrInvalidIdentifier},
+	}
+	for _, tt := range tests {
+		var rows []map[string]interface{}
+		err := tt.qb.Find(&rows)
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestExistsSQL(t *testing.T) {
+	c := newTestClient(t)
+	_, err := c.Table("users").WhereEq("status", 1).OrderByDesc("id").Exists()
+	sql, vars := c.lastSQL(t, err)
+	want := "SELECT 1 FROM `users` WHERE `status` = ? LIMIT 1"
+	if sql != want || !reflect.DeepEqual(vars, []interface{}{1}) {
+		t.Errorf("got %q %v, want %q", sql, vars, want)
+	}
+	if strings.Contains(sql, "ORDER BY") {
+		t.Error("Exists should drop ORDER BY")
+	}
+}
@@ -3,6 +3,8 @@ package grds
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -45,6 +47,36 @@ func RollbackTo(tx *gorm.DB, name string) error {
 	return tx.RollbackTo(name).Error
 }
 
+// SetLockWaitTimeout 设置当前会话的行锁等待超时（innodb_lock_wait_timeout），按秒向上取整，最小 1 秒
+//
+// 会话变量作用于连接，只应在事务（固定连接）中调用，并在结束前调用 ResetLockWaitTimeout 恢复，
+// 否则会影响连接池中后续使用该连接的请求。优先使用 TxManager.WithLockWaitTimeout。
+func SetLockWaitTimeout(tx *gorm.DB, timeout time.Duration) error {
+	return tx.Exec(setLockWaitTimeoutSQL(timeout)).Error
+}
+
+// ResetLockWaitTimeout 把当前会话的行锁等待超时恢复为全局默认值
+func ResetLockWaitTimeout(tx *gorm.DB) error {
+	return tx.Exec(resetLockWaitTimeoutSQL).Error
+}
+
+const resetLockWaitTimeoutSQL = "SET SESSION innodb_lock_wait_timeout = DEFAULT"
+
+// setLockWaitTimeoutSQL 设置行锁等待超时的语句
+func setLockWaitTimeoutSQL(timeout time.Duration) string {
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("SET SESSION innodb_lock_wait_timeout = %d", seconds)
+}
+
+// inTransaction 判断语句是否在事务中执行
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
 // TxManager 事务管理器
 type TxManager struct {
 	db *gorm.DB
@@ -101,3 +133,68 @@ func (tm *TxManager) WithSavepoint(tx *gorm.DB, name string, fc func(*gorm.DB) e
 
 	return nil
 }
+
+// WithLockWaitTimeout 在事务中设置行锁等待超时，事务结束后恢复默认值
+//
+// 事务在单独取出的连接上执行。恢复使用不会被取消的 context，恢复失败时返回错误并丢弃该连接，
+// 避免修改过的会话变量随连接回到连接池。
+//
+//	err := tm.WithLockWaitTimeout(2*time.Second, func(tx *gorm.DB) error {
+//	    return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error
+//	})
+func (tm *TxManager) WithLockWaitTimeout(timeout time.Duration, fc TxFunc, opts ...*sql.TxOptions) (err error) {
+	if inTransaction(tm.db) {
+		return tm.nestedLockWaitTimeout(timeout, fc, opts...)
+	}
+	sqlDB, err := tm.db.DB()
+	if err != nil {
+		return err
+	}
+	ctx := tm.db.Statement.Context
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, resetErr := conn.ExecContext(context.Background(), resetLockWaitTimeoutSQL); resetErr != nil {
+			// 会话变量无法恢复的连接不能归还连接池
+			discardConn(conn)
+			if err == nil {
+				err = resetErr
+			}
+			return
+		}
+		_ = conn.Close()
+	}()
+
+	if _, err := conn.ExecContext(ctx, setLockWaitTimeoutSQL(timeout)); err != nil {
+		return err
+	}
+	// 指定 Context 使 Session 复制 Statement，替换连接池不影响 tm.db
+	db := tm.db.Session(&gorm.Session{Context: ctx})
+	db.Statement.ConnPool = withConn(tm.db.Statement.ConnPool, conn)
+	return db.Transaction(fc, opts...)
+}
+
+// nestedLockWaitTimeout 已在事务中时，在外层事务持有的连接上设置和恢复
+func (tm *TxManager) nestedLockWaitTimeout(timeout time.Duration, fc TxFunc, opts ...*sql.TxOptions) error {
+	return tm.Execute(func(tx *gorm.DB) (err error) {
+		if err := SetLockWaitTimeout(tx, timeout); err != nil {
+			return err
+		}
+		defer func() {
+			if resetErr := ResetLockWaitTimeout(tx.WithContext(context.Background())); err == nil {
+				err = resetErr
+			}
+		}()
+		return fc(tx)
+	}, opts...)
+}
+
+// withConn 用 conn 替换连接池，保留查询缓存的包装
+func withConn(pool gorm.ConnPool, conn *sql.Conn) gorm.ConnPool {
+	if p, ok := pool.(*cachePool); ok {
+		return &cachePool{ConnPool: conn, cache: p.cache}
+	}
+	return conn
+}
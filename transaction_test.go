@@ -0,0 +1,81 @@
+package grds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+func TestWithLockWaitTimeout(t *testing.T) {
+	errFc := errors.New("fc failed")
+	errReset := errors.New("reset failed")
+	tests := []struct {
+		name      string
+		fcErr     error
+		resetErr  error
+		want      error
+		discarded bool // 恢复失败的连接不能放回连接池
+	}{
+		{"commit", nil, nil, nil, false},
+		{"rollback", errFc, nil, errFc, false},
+		{"reset fails", nil, errReset, errReset, true},
+		{"fc error wins", errFc, errReset, errFc, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mock := newMockClient(t, nil)
+			mock.ExpectExec("SET SESSION innodb_lock_wait_timeout = 2").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE `users` SET `score`=? WHERE id = ?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.fcErr != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+			reset := mock.ExpectExec(resetLockWaitTimeoutSQL)
+			if tt.resetErr != nil {
+				reset.WillReturnError(tt.resetErr)
+			} else {
+				reset.WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			err := NewTxManager(c.DB()).WithLockWaitTimeout(1500*time.Millisecond, func(tx *gorm.DB) error {
+				if err := tx.Model(&nullableUser{}).Where("id = ?", 1).Update("score", 1).Error; err != nil {
+					return err
+				}
+				return tt.fcErr
+			})
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("WithLockWaitTimeout() = %v, want %v", err, tt.want)
+			}
+
+			sqlDB, _ := c.SqlDB()
+			stats := sqlDB.Stats()
+			if discarded := stats.OpenConnections == 0; discarded != tt.discarded {
+				t.Errorf("connection discarded = %v, want %v (%+v)", discarded, tt.discarded, stats)
+			}
+		})
+	}
+}
+
+func TestWithLockWaitTimeoutCanceled(t *testing.T) {
+	c, mock := newMockClient(t, nil)
+	mock.ExpectExec("SET SESSION innodb_lock_wait_timeout = 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	// context 取消后仍在同一连接上恢复
+	mock.ExpectExec(resetLockWaitTimeoutSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := NewTxManager(c.DB().WithContext(ctx)).WithLockWaitTimeout(time.Second, func(tx *gorm.DB) error {
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WithLockWaitTimeout() = %v, want context.Canceled", err)
+	}
+}
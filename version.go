@@ -0,0 +1,247 @@
+package grds
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject 乐观锁冲突：记录已被其他人修改或删除
+var ErrStaleObject = errors.New("stale object")
+
+// StaleObjectError 乐观锁冲突错误，errors.Is(err, ErrStaleObject) 为 true
+type StaleObjectError struct {
+	Table   string
+	Version int64 // 更新时持有的版本号
+	Current int64 // 数据库中的当前版本号，记录不存在时为 0
+}
+
+// Error 实现 error 接口
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("stale object: %s version %d, current version %d", e.Table, e.Version, e.Current)
+}
+
+// Is 支持 errors.Is(err, ErrStaleObject)
+func (e *StaleObjectError) Is(target error) bool {
+	return target == ErrStaleObject
+}
+
+// DefaultStaleRetries RetryOnStale 的默认尝试次数
+const DefaultStaleRetries = 3
+
+// RetryOnStale 执行“读取-修改-保存”闭包，遇到 ErrStaleObject 时重新执行，最多 attempts 次
+//
+// 闭包内需要重新读取记录：
+//
+//	err := grds.RetryOnStale(3, func() error {
+//	    var order Order
+//	    if err := grds.Model(&Order{}).WhereEq("id", id).First(&order); err != nil {
+//	        return err
+//	    }
+//	    order.Status = 2
+//	    return grds.Save(&order)
+//	})
+func RetryOnStale(attempts int, fn func() error) error {
+	if attempts <= 0 {
+		attempts = DefaultStaleRetries
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); !errors.Is(err, ErrStaleObject) {
+			return err
+		}
+	}
+	return err
+}
+
+// versionStateKey 更新前后传递版本状态的键
+const versionStateKey = "grds:version"
+
+// versionState 一次更新的版本状态
+type versionState struct {
+	field    *schema.Field
+	expected int64 // 持有的版本号
+	checked  bool  // 是否带了 version = ? 条件
+}
+
+// versionFields 模型的版本字段缓存
+var versionFields sync.Map // *schema.Schema -> *schema.Field
+
+// versionField 返回带 grds:"version" 标签的字段
+func versionField(s *schema.Schema) *schema.Field {
+	if v, ok := versionFields.Load(s); ok {
+		field, _ := v.(*schema.Field)
+		return field
+	}
+
+	var found *schema.Field
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		for _, part := range strings.Split(field.Tag.Get("grds"), ";") {
+			if strings.EqualFold(strings.TrimSpace(part), "version") {
+				found = field
+			}
+		}
+	}
+	versionFields.Store(s, found)
+	return found
+}
+
+// versionInt 把版本字段值转换为 int64
+func versionInt(value interface{}) int64 {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	}
+	return 0
+}
+
+// versionBeforeCreate 新记录的版本号为 0 时设置为 1
+func versionBeforeCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	field := versionField(stmt.Schema)
+	if field == nil {
+		return
+	}
+
+	setInitial := func(rv reflect.Value) {
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			_ = db.AddError(field.Set(stmt.Context, rv, 1))
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		setInitial(stmt.ReflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if elem := reflect.Indirect(stmt.ReflectValue.Index(i)); elem.Kind() == reflect.Struct {
+				setInitial(elem)
+			}
+		}
+	}
+}
+
+// versionBeforeUpdate 更新时追加 version = version + 1，单条记录追加 WHERE version = ?
+//
+// 为了替换调用方传入的版本值，这里提前生成 UPDATE 语句，gorm:update 只负责执行。
+func versionBeforeUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	field := versionField(stmt.Schema)
+	if field == nil {
+		return
+	}
+
+	state := &versionState{field: field}
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		if value, zero := field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			state.expected, state.checked = versionInt(value), true
+		}
+	}
+
+	for _, c := range stmt.Schema.UpdateClauses {
+		stmt.AddClause(c)
+	}
+	stmt.AddClauseIfNotExists(clause.Update{})
+	set := callbacks.ConvertToAssignments(stmt)
+	if len(set) == 0 || db.Error != nil {
+		return
+	}
+
+	assignments := make(clause.Set, 0, len(set)+1)
+	for _, a := range set {
+		if a.Column.Name != field.DBName {
+			assignments = append(assignments, a)
+		}
+	}
+	column := clause.Column{Name: field.DBName}
+	assignments = append(assignments, clause.Assignment{
+		Column: column,
+		Value:  clause.Expr{SQL: "? + 1", Vars: []interface{}{column}},
+	})
+
+	if state.checked {
+		groupWhereOr(stmt)
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: state.expected},
+		}})
+	}
+
+	stmt.AddClause(assignments)
+	stmt.Build(stmt.BuildClauses...)
+	delete(stmt.Clauses, "SET")
+	db.InstanceSet(versionStateKey, state)
+}
+
+// versionAfterUpdate 更新成功时递增内存中的版本号，未更新到记录时返回 StaleObjectError
+func versionAfterUpdate(db *gorm.DB) {
+	v, ok := db.InstanceGet(versionStateKey)
+	if !ok || db.Error != nil || db.DryRun {
+		return
+	}
+	state := v.(*versionState)
+	if !state.checked {
+		return
+	}
+
+	stmt := db.Statement
+	isStruct := stmt.ReflectValue.Kind() == reflect.Struct
+	if db.RowsAffected > 0 {
+		// Model 传入非指针结构体或 map 时无法回写
+		if isStruct && stmt.ReflectValue.CanAddr() {
+			_ = db.AddError(state.field.Set(stmt.Context, stmt.ReflectValue, state.expected+1))
+		}
+		return
+	}
+
+	staleErr := &StaleObjectError{Table: stmt.Table, Version: state.expected}
+	conds := make([]clause.Expression, 0, len(stmt.Schema.PrimaryFields))
+	for _, pk := range stmt.Schema.PrimaryFields {
+		if !isStruct {
+			break
+		}
+		value, zero := pk.ValueOf(stmt.Context, stmt.ReflectValue)
+		if zero {
+			conds = conds[:0]
+			break
+		}
+		conds = append(conds, clause.Eq{Column: clause.Column{Name: pk.DBName}, Value: value})
+	}
+	if len(conds) > 0 {
+		var current int64
+		tx := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+			Table(stmt.Table).Select(stmt.Quote(state.field.DBName)).Where(clause.And(conds...)).Limit(1).Scan(&current)
+		if tx.Error == nil {
+			staleErr.Current = current
+		}
+	}
+	_ = db.AddError(staleErr)
+}
+
+// registerVersion 注册乐观锁回调
+func registerVersion(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("grds:version_create", versionBeforeCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").After("grds:soft_delete_update").Register("grds:version_update", versionBeforeUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Before("gorm:after_update").Register("grds:version_check", versionAfterUpdate)
+}
@@ -0,0 +1,84 @@
+package grds
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type versionedItem struct {
+	ID      int64
+	Name    string
+	Version int64 `grds:"version"`
+}
+
+func TestVersionUpdateSQL(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name     string
+		qb       *QueryBuilder
+		values   []interface{}
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name:     "checked",
+			qb:       c.Model(&versionedItem{ID: 1, Version: 3}),
+			values:   []interface{}{"name", "a"},
+			wantSQL:  "UPDATE `versioned_items` SET `name`=?,`version`=`version` + 1 WHERE `id` = ? AND `versioned_items`.`version` = ?",
+			wantVars: []interface{}{"a", int64(1), int64(3)},
+		},
+		{
+			name:     "bulk",
+			qb:       c.Model(&versionedItem{}).WhereEq("name", "a"),
+			values:   []interface{}{"name", "b"},
+			wantSQL:  "UPDATE `versioned_items` SET `name`=?,`version`=`version` + 1 WHERE `name` = ?",
+			wantVars: []interface{}{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := toSQL(t, tt.qb, SQLUpdate, tt.values...)
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+// runVersionAfterUpdate 演练生成语句后，模拟更新了 affected 行再执行 versionAfterUpdate
+func runVersionAfterUpdate(t *testing.T, db *gorm.DB, affected int64) *gorm.DB {
+	t.Helper()
+	if db.Error != nil {
+		t.Fatalf("dry run: %v", db.Error)
+	}
+	db.Config.DryRun = false
+	db.RowsAffected = affected
+	versionAfterUpdate(db)
+	return db
+}
+
+func TestVersionAfterUpdate(t *testing.T) {
+	c := newTestClient(t)
+	// runVersionAfterUpdate 会修改会话的 Config，每次使用新的会话
+	dryRun := func() *gorm.DB {
+		return c.DB().Session(&gorm.Session{})
+	}
+
+	item := &versionedItem{ID: 1, Version: 3}
+	db := runVersionAfterUpdate(t, dryRun().Model(item).Update("name", "a"), 1)
+	if db.Error != nil || item.Version != 4 {
+		t.Errorf("pointer model: err = %v, version = %d, want 4", db.Error, item.Version)
+	}
+
+	// 非指针结构体无法回写，不应报错或 panic
+	value := versionedItem{ID: 1, Version: 3}
+	db = runVersionAfterUpdate(t, dryRun().Model(value).Update("name", "a"), 1)
+	if db.Error != nil || value.Version != 3 {
+		t.Errorf("value model: err = %v, version = %d, want 3", db.Error, value.Version)
+	}
+}